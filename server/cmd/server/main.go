@@ -6,27 +6,61 @@ import (
 	"log"
 	"net/http"
 	"time"
+	_ "time/tzdata" // capture time zones, even on images without zoneinfo
 
 	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/captcha"
 	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/feed"
 	"github.com/ShinysArc/photography-portfolio/server/internal/handlers"
+	"github.com/ShinysArc/photography-portfolio/server/internal/inbox"
 	"github.com/ShinysArc/photography-portfolio/server/internal/mail"
 	"github.com/ShinysArc/photography-portfolio/server/internal/middleware"
+	"github.com/ShinysArc/photography-portfolio/server/internal/og"
+	"github.com/ShinysArc/photography-portfolio/server/internal/sitemap"
+	"github.com/ShinysArc/photography-portfolio/server/internal/stories"
 )
 
 func main() {
 	cfg := config.Load()
 
+	// Derived documents are rebuilt whenever cache.json changes.
+	cache.OnPublish(feed.Rebuild)
+	cache.OnPublish(sitemap.Rebuild)
+	cache.OnPublish(og.Prune)
+
 	mailer, err := mail.NewMailer(cfg)
 	if err != nil {
 		log.Printf("mail disabled: %v", err)
+	} else {
+		log.Printf("mail transport: %s", mailer.Transport())
+	}
+
+	verifier, err := captcha.New(cfg)
+	if err != nil {
+		log.Fatalf("captcha: %v", err)
 	}
 
 	mux := http.NewServeMux()
-	handlers.RegisterAll(mux, cfg, mailer)
+	handlers.RegisterAll(mux, cfg, mailer, verifier)
+
+	perIP, err := middleware.ParseRateLimits(cfg.RateLimits)
+	if err != nil {
+		log.Fatalf("RATE_LIMITS: %v", err)
+	}
+	global, err := middleware.ParseRateLimits(cfg.RateLimitsGlobal)
+	if err != nil {
+		log.Fatalf("RATE_LIMITS_GLOBAL: %v", err)
+	}
+	proxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("TRUSTED_PROXIES: %v", err)
+	}
+	middleware.SetTrustedProxies(proxies)
 
-	// Logging -> CORS -> Handlers
+	// Logging -> CORS -> Rate limit -> Handlers
 	var handler http.Handler = mux
+	handler = middleware.WithRateLimit(perIP, global, handler)
 	handler = middleware.WithLogging(handler)
 	handler = middleware.WithCORS(cfg.AllowOrigin, handler)
 
@@ -42,8 +76,17 @@ func main() {
 			return
 		}
 		log.Printf("startup refresh: OK album=%s items=%d in %s", data.Album.ID, len(data.Items), time.Since(start).Truncate(time.Millisecond))
+		for _, msg := range stories.Check(cfg, data) {
+			log.Printf("startup refresh: warning: %s", msg)
+		}
 	}()
 
+	go cache.RunScheduler(context.Background(), cfg)
+	go inbox.RunRetention(context.Background(), cfg)
+	if mailer != nil {
+		go mail.RunQueue(context.Background(), cfg, mailer)
+	}
+
 	srv := &http.Server{
 		Addr:              ":" + cfg.Port,
 		Handler:           handler,
@@ -7,9 +7,7 @@ toolchain go1.24.7
 require (
 	github.com/galdor/go-thumbhash v1.0.0
 	github.com/wneessen/go-mail v0.7.2
+	golang.org/x/image v0.31.0
 )
 
-require (
-	golang.org/x/image v0.31.0 // indirect
-	golang.org/x/text v0.29.0 // indirect
-)
+require golang.org/x/text v0.29.0 // indirect
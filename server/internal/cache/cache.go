@@ -7,19 +7,32 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/fsutil"
 	"github.com/ShinysArc/photography-portfolio/server/internal/immich"
 	"github.com/ShinysArc/photography-portfolio/server/internal/store"
 )
 
 type Item struct {
-	ID                 string       `json:"id"`
-	OriginalFileName   *string      `json:"originalFileName,omitempty"`
-	Exif               immich.Exif  `json:"exif"`
-	Tags               []immich.Tag `json:"tags"`
-	PreviewPath        string       `json:"previewPath,omitempty"` // "preview/<id>.jpg"
-	ThumbHash          string       `json:"thumbHash,omitempty"`   // base64, not data URL
+	ID               string       `json:"id"`
+	OriginalFileName *string      `json:"originalFileName,omitempty"`
+	Exif             immich.Exif  `json:"exif"`
+	Display          *ExifDisplay `json:"display,omitempty"` // normalized from Exif
+	Taken            *string      `json:"taken,omitempty"`   // RFC3339 in the capture's own zone
+	Tags             []immich.Tag `json:"tags"`
+	Description      *string      `json:"description,omitempty"`
+	Rating           *int         `json:"rating,omitempty"`
+	Favorite         bool         `json:"favorite,omitempty"`
+	Location         *Location    `json:"location,omitempty"`
+	PreviewPath      string       `json:"previewPath,omitempty"`   // "preview/<id>.jpg"
+	ThumbnailPath    string       `json:"thumbnailPath,omitempty"` // "thumbnail/<id>.webp"
+	ThumbHash        string       `json:"thumbHash,omitempty"`     // base64, not data URL
+	PublishAt        *string      `json:"publishAt,omitempty"`     // RFC3339, from a publish: marker
+	Published        *string      `json:"published,omitempty"`     // RFC3339, PublishAt or first seen by a refresh
+	Unlisted         bool         `json:"unlisted,omitempty"`
+	InvalidMarkers   []string     `json:"invalidMarkers,omitempty"` // unparseable publish: markers; staging only
 }
 
 type File struct {
@@ -28,15 +41,29 @@ type File struct {
 		Name       string `json:"name"`
 		AssetCount int    `json:"assetCount"`
 	} `json:"album"`
-	Items []Item `json:"items"`
+	Items    []Item   `json:"items"`
+	Featured []string `json:"featured"` // favorite item IDs, best rated first
+
+	// AlbumOrder is Immich's asset order, kept in staging only so the
+	// "album" policy survives reordering.
+	AlbumOrder []string `json:"albumOrder,omitempty"`
 }
 
 func path(cfg config.Config) string {
 	return filepath.Join(cfg.DataDir, "cache.json")
 }
 
+// stagingPath holds every album item, including scheduled and unlisted ones.
+// It lives in STATE_DIR because DATA_DIR is served to the public.
+func stagingPath(cfg config.Config) string {
+	return filepath.Join(cfg.StateDir, "staging.json")
+}
+
 func EnsureDir(cfg config.Config) error {
-	return os.MkdirAll(cfg.DataDir, 0o755)
+	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
+		return err
+	}
+	return os.MkdirAll(cfg.StateDir, 0o700)
 }
 
 func Refresh(ctx context.Context, cfg config.Config) (File, error) {
@@ -52,6 +79,8 @@ func Refresh(ctx context.Context, cfg config.Config) (File, error) {
 	out.Album.AssetCount = meta.AssetCount
 	out.Items = make([]Item, len(ids))
 
+	names := loadAliases(cfg)
+
 	const workers = 8
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -86,13 +115,40 @@ func Refresh(ctx context.Context, cfg config.Config) (File, error) {
 			if full.ExifInfo != nil {
 				ex = *full.ExifInfo
 			}
+			vis, tags, desc := visibilityOf(full.Tags, ex.Description)
 
-			ch <- res{i: i, item: Item{
+			// Description and rating are surfaced on the item itself. Only
+			// 1-5 stars count: 0 is unrated and -1 is Immich's "rejected",
+			// which is published as unrated rather than hidden.
+			rating := ex.Rating
+			if rating != nil && (*rating < 1 || *rating > 5) {
+				rating = nil
+			}
+			ex.Description, ex.Rating = nil, nil
+			loc := locationOf(cfg, &ex)
+
+			item := Item{
 				ID:               full.ID,
 				OriginalFileName: full.OriginalFileName,
 				Exif:             ex,
-				Tags:             full.Tags,
-			}}
+				Display:          names.display(ex),
+				Tags:             tags,
+				Description:      desc,
+				Rating:           rating,
+				Favorite:         full.IsFavorite,
+				Location:         loc,
+				Unlisted:         vis.Unlisted,
+				InvalidMarkers:   vis.Invalid,
+			}
+			if vis.PublishAt != nil {
+				at := vis.PublishAt.UTC().Format(time.RFC3339)
+				item.PublishAt = &at
+			}
+			if t, ok := item.LocalTakenAt(); ok {
+				taken := t.Format(time.RFC3339)
+				item.Taken = &taken
+			}
+			ch <- res{i: i, item: item}
 		}
 	}
 
@@ -109,41 +165,182 @@ func Refresh(ctx context.Context, cfg config.Config) (File, error) {
 		}
 		out.Items[r.i] = r.item
 	}
-
-	// Prefetch files & prune
-	_ = store.EnsureDirs(cfg)
-	_ = store.Prefetch(ctx, cfg, ids)
-	keep := make(map[string]struct{}, len(ids))
-	for _, id := range ids {
-		keep[id] = struct{}{}
-	}
-	_ = store.Prune(cfg, keep)
-
-	if pathIdx, err := store.BuildPathIndex(cfg); err == nil {
-		for i := range out.Items {
-			if p, ok := pathIdx[out.Items[i].ID]; ok {
-				out.Items[i].PreviewPath = p.Preview
-				abs := filepath.Join(cfg.DataDir, out.Items[i].PreviewPath)
-				if th, err := store.ComputeThumbHashFromFile(abs); err == nil {
-					out.Items[i].ThumbHash = th
-				}
-			}
-		}
-	} else {
-		log.Printf("BuildPathIndex error: %v", err)
-	}
+	out.AlbumOrder = ids
 
 	if err := EnsureDir(cfg); err != nil {
 		return File{}, err
 	}
+
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	stampPublished(cfg, out.Items)
+	ord, err := GetOrdering(cfg, out.Album.ID)
+	if err != nil {
+		log.Printf("ordering: %v", err)
+		ord = Ordering{Policy: cfg.AlbumOrder}
+	}
+	applyOrdering(out.Items, ord)
+
 	b, _ := json.MarshalIndent(out, "", "  ")
-	if err := os.WriteFile(filepath.Join(cfg.DataDir, "cache.json"), b, 0o644); err != nil {
+	if err := fsutil.WriteAtomic(stagingPath(cfg), b, 0o600); err != nil {
 		return File{}, err
 	}
 
-	return out, nil
+	pub, next, err := publish(ctx, cfg, time.Now())
+	if err != nil {
+		return File{}, err
+	}
+	notifyScheduler(next)
+	return pub, nil
+}
+
+func readStaging(cfg config.Config) (File, error) {
+	var all File
+	b, err := os.ReadFile(stagingPath(cfg))
+	if err != nil {
+		return all, err
+	}
+	return all, json.Unmarshal(b, &all)
+}
+
+// stampPublished sets Published from the publish marker, or else keeps the
+// time the item was first seen by an earlier refresh.
+func stampPublished(cfg config.Config, items []Item) {
+	seen := map[string]*string{}
+	if prev, err := readStaging(cfg); err == nil {
+		for _, it := range prev.Items {
+			seen[it.ID] = it.Published
+		}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for i := range items {
+		switch {
+		case items[i].PublishAt != nil:
+			items[i].Published = items[i].PublishAt
+		case seen[items[i].ID] != nil:
+			items[i].Published = seen[items[i].ID]
+		default:
+			items[i].Published = &now
+		}
+	}
+}
+
+var publishHooks []func(config.Config, File)
+
+// OnPublish registers fn to run after every write of cache.json, e.g. to
+// rebuild derived documents. Register hooks before serving.
+func OnPublish(fn func(config.Config, File)) {
+	publishHooks = append(publishHooks, fn)
+}
+
+// publishMu serializes writers of staging.json and cache.json: Refresh,
+// Reorder and the scheduler all publish.
+var publishMu sync.Mutex
+
+// Publish writes cache.json from the staging file, keeping only items that
+// are public at now. It also returns the earliest pending embargo, or the
+// zero time when nothing is scheduled.
+func Publish(ctx context.Context, cfg config.Config, now time.Time) (File, time.Time, error) {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+	return publish(ctx, cfg, now)
+}
+
+// publish is Publish for callers already holding publishMu.
+func publish(ctx context.Context, cfg config.Config, now time.Time) (File, time.Time, error) {
+	all, err := readStaging(cfg)
+	if err != nil {
+		return File{}, time.Time{}, err
+	}
+
+	pub := all
+	pub.AlbumOrder = nil
+	pub.Items = make([]Item, 0, len(all.Items))
+	var next time.Time
+	for _, it := range all.Items {
+		if it.isPublic(now) {
+			pub.Items = append(pub.Items, it)
+			continue
+		}
+		if it.Unlisted || it.PublishAt == nil {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, *it.PublishAt); err == nil && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	syncRenditions(ctx, cfg, pub.Items)
+	pub.Featured = featured(pub.Items, cfg.FeaturedMax)
+
+	out, _ := json.MarshalIndent(pub, "", "  ")
+	if err := fsutil.WriteAtomic(path(cfg), out, 0o644); err != nil {
+		return File{}, time.Time{}, err
+	}
+	for _, fn := range publishHooks {
+		fn(cfg, pub)
+	}
+	return pub, next, nil
+}
+
+// syncRenditions makes the rendition directories hold exactly the public
+// items, since DATA_DIR is served as is: missing files are fetched, files of
+// hidden or removed assets are deleted, and paths and ThumbHashes are filled
+// in. ThumbHashes are carried over from the previous cache.json.
+func syncRenditions(ctx context.Context, cfg config.Config, items []Item) {
+	ids := make([]string, len(items))
+	keep := make(map[string]struct{}, len(items))
+	for i, it := range items {
+		ids[i] = it.ID
+		keep[it.ID] = struct{}{}
+	}
+	_ = store.EnsureDirs(cfg)
+	if err := store.Prefetch(ctx, cfg, ids); err != nil {
+		log.Printf("prefetch: %v", err)
+	}
+	if err := store.Prune(cfg, keep); err != nil {
+		log.Printf("prune: %v", err)
+	}
+
+	pathIdx, err := store.BuildPathIndex(cfg)
+	if err != nil {
+		log.Printf("BuildPathIndex error: %v", err)
+		return
+	}
+	hashes := map[string]string{}
+	if prev, err := Load(cfg); err == nil {
+		for _, it := range prev.Items {
+			hashes[it.ID] = it.ThumbHash
+		}
+	}
+	for i := range items {
+		p := pathIdx[items[i].ID]
+		items[i].PreviewPath = p.Preview
+		items[i].ThumbnailPath = p.Thumbnail
+		if p.Preview == "" {
+			continue
+		}
+		if th := hashes[items[i].ID]; th != "" {
+			items[i].ThumbHash = th
+			continue
+		}
+		abs := filepath.Join(cfg.DataDir, p.Preview)
+		if th, err := store.ComputeThumbHashFromFile(abs); err == nil {
+			items[i].ThumbHash = th
+		}
+	}
 }
 
 func Read(cfg config.Config) ([]byte, error) {
 	return os.ReadFile(path(cfg))
 }
+
+// Load reads and decodes the public cache.
+func Load(cfg config.Config) (File, error) {
+	var f File
+	b, err := Read(cfg)
+	if err != nil {
+		return f, err
+	}
+	return f, json.Unmarshal(b, &f)
+}
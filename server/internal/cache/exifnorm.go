@@ -0,0 +1,307 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/immich"
+)
+
+// ExifDisplay holds display-ready EXIF strings derived from the raw values.
+type ExifDisplay struct {
+	Camera          string   `json:"camera,omitempty"`        // "Canon EOS R6 Mark II"
+	Lens            string   `json:"lens,omitempty"`          // "Canon RF 35mm f/1.8 Macro IS STM"
+	Exposure        string   `json:"exposure,omitempty"`      // "1/250 s"
+	Aperture        string   `json:"aperture,omitempty"`      // "f/2.8"
+	FocalLength     string   `json:"focalLength,omitempty"`   // "35 mm"
+	FocalLength35   string   `json:"focalLength35,omitempty"` // "56 mm" (35mm equivalent)
+	ISO             string   `json:"iso,omitempty"`           // "ISO 400"
+	ExposureSeconds *float64 `json:"exposureSeconds,omitempty"`
+	FocalLength35mm *float64 `json:"focalLength35mm,omitempty"`
+}
+
+type cameraAlias struct {
+	Name       string  `json:"name,omitempty"`
+	CropFactor float64 `json:"cropFactor,omitempty"`
+}
+
+// aliases maps raw EXIF strings to clean names. Keys are matched
+// case-insensitively; EXIF_ALIASES entries override the built-in ones.
+type aliases struct {
+	Makes   map[string]string      `json:"makes"`
+	Cameras map[string]cameraAlias `json:"cameras"`
+	Lenses  map[string]string      `json:"lenses"`
+}
+
+var builtinAliases = aliases{
+	Makes: map[string]string{
+		"canon":                       "Canon",
+		"nikon corporation":           "Nikon",
+		"nikon":                       "Nikon",
+		"sony":                        "Sony",
+		"fujifilm":                    "Fujifilm",
+		"olympus imaging corp.":       "Olympus",
+		"olympus corporation":         "Olympus",
+		"om digital solutions":        "OM System",
+		"panasonic":                   "Panasonic",
+		"ricoh imaging company, ltd.": "Ricoh",
+		"pentax corporation":          "Pentax",
+		"leica camera ag":             "Leica",
+		"apple":                       "Apple",
+		"google":                      "Google",
+		"samsung":                     "Samsung",
+		"dji":                         "DJI",
+		"hasselblad":                  "Hasselblad",
+	},
+	Cameras: map[string]cameraAlias{
+		"canon eos r6m2": {Name: "Canon EOS R6 Mark II", CropFactor: 1},
+		"canon eos r5m2": {Name: "Canon EOS R5 Mark II", CropFactor: 1},
+		"sony ilce-7m3":  {Name: "Sony α7 III", CropFactor: 1},
+		"sony ilce-7m4":  {Name: "Sony α7 IV", CropFactor: 1},
+		"sony ilce-7rm4": {Name: "Sony α7R IV", CropFactor: 1},
+		"sony ilce-6400": {Name: "Sony α6400", CropFactor: 1.5},
+		"sony ilce-6700": {Name: "Sony α6700", CropFactor: 1.5},
+		"nikon z 6_2":    {Name: "Nikon Z 6II", CropFactor: 1},
+		"nikon z 7_2":    {Name: "Nikon Z 7II", CropFactor: 1},
+	},
+	Lenses: map[string]string{},
+}
+
+func loadAliases(cfg config.Config) aliases {
+	a := aliases{
+		Makes:   map[string]string{},
+		Cameras: map[string]cameraAlias{},
+		Lenses:  map[string]string{},
+	}
+	merge := func(src aliases) {
+		for k, v := range src.Makes {
+			a.Makes[strings.ToLower(k)] = v
+		}
+		for k, v := range src.Cameras {
+			a.Cameras[strings.ToLower(k)] = v
+		}
+		for k, v := range src.Lenses {
+			a.Lenses[strings.ToLower(k)] = v
+		}
+	}
+	merge(builtinAliases)
+
+	if cfg.ExifAliases == "" {
+		return a
+	}
+	b, err := os.ReadFile(cfg.ExifAliases)
+	if err != nil {
+		log.Printf("exif aliases: %v", err)
+		return a
+	}
+	var user aliases
+	if err := json.Unmarshal(b, &user); err != nil {
+		log.Printf("exif aliases: %s: %v", cfg.ExifAliases, err)
+		return a
+	}
+	merge(user)
+	return a
+}
+
+func (a aliases) display(ex immich.Exif) *ExifDisplay {
+	d := &ExifDisplay{}
+	crop := 0.0
+	if camera, c := a.camera(ex.Make, ex.Model); camera != "" {
+		d.Camera, crop = camera, c
+	}
+	if ex.LensModel != nil {
+		lens := strings.TrimSpace(*ex.LensModel)
+		if alias, ok := a.Lenses[strings.ToLower(lens)]; ok {
+			lens = alias
+		}
+		d.Lens = lens
+	}
+	if ex.ExposureTime != nil {
+		if sec, ok := parseExposure(*ex.ExposureTime); ok {
+			d.ExposureSeconds = &sec
+			d.Exposure = formatExposure(sec)
+		}
+	}
+	if ex.FNumber != nil && *ex.FNumber > 0 {
+		d.Aperture = "f/" + trimFloat(*ex.FNumber, 1)
+	}
+	if ex.FocalLength != nil && *ex.FocalLength > 0 {
+		d.FocalLength = formatFocal(*ex.FocalLength)
+		if crop > 0 {
+			eq := math.Round(*ex.FocalLength * crop)
+			d.FocalLength35mm = &eq
+			d.FocalLength35 = formatFocal(eq)
+		}
+	}
+	if ex.ISO != nil && *ex.ISO > 0 {
+		d.ISO = "ISO " + strconv.Itoa(*ex.ISO)
+	}
+
+	if *d == (ExifDisplay{}) {
+		return nil
+	}
+	return d
+}
+
+// camera builds "Make Model" without the make repeated inside the model,
+// then applies the alias table. It also returns the crop factor, if known.
+func (a aliases) camera(mk, model *string) (string, float64) {
+	var rawMake, rawModel string
+	if mk != nil {
+		rawMake = strings.TrimSpace(*mk)
+	}
+	if model != nil {
+		rawModel = strings.TrimSpace(*model)
+	}
+	if rawMake == "" && rawModel == "" {
+		return "", 0
+	}
+
+	cleanMake := rawMake
+	if alias, ok := a.Makes[strings.ToLower(rawMake)]; ok {
+		cleanMake = alias
+	}
+	prefixes := []string{rawMake, cleanMake}
+	if words := strings.Fields(rawMake); len(words) > 1 {
+		prefixes = append(prefixes, words[0]) // "NIKON CORPORATION" -> "NIKON"
+	}
+	rest := rawModel
+	for _, prefix := range prefixes {
+		if prefix != "" && len(rest) > len(prefix) && strings.EqualFold(rest[:len(prefix)], prefix) {
+			rest = strings.TrimSpace(rest[len(prefix):])
+			break
+		}
+	}
+	name := strings.TrimSpace(cleanMake + " " + rest)
+
+	for _, key := range []string{name, rawModel} {
+		if alias, ok := a.Cameras[strings.ToLower(key)]; ok {
+			if alias.Name != "" {
+				name = alias.Name
+			}
+			if alias.CropFactor > 0 {
+				return name, alias.CropFactor
+			}
+			break
+		}
+	}
+	return name, guessCropFactor(cleanMake, rest)
+}
+
+// guessCropFactor covers common sensor families; unknown bodies return 0.
+func guessCropFactor(mk, model string) float64 {
+	m := strings.ToUpper(model)
+	switch mk {
+	case "Fujifilm":
+		if strings.HasPrefix(m, "GFX") {
+			return 0.79
+		}
+		if strings.HasPrefix(m, "X") {
+			return 1.5
+		}
+	case "Olympus", "OM System":
+		return 2
+	case "Panasonic":
+		if strings.HasPrefix(m, "DC-G") || strings.HasPrefix(m, "DMC-G") {
+			return 2
+		}
+		if strings.HasPrefix(m, "DC-S") {
+			return 1
+		}
+	case "Sony":
+		if strings.HasPrefix(m, "ILCE-7") || strings.HasPrefix(m, "ILCE-9") || strings.HasPrefix(m, "ILCE-1") {
+			return 1
+		}
+		if strings.HasPrefix(m, "ILCE-6") {
+			return 1.5
+		}
+	case "Canon":
+		switch {
+		case strings.HasPrefix(m, "EOS R7"), strings.HasPrefix(m, "EOS R10"), strings.HasPrefix(m, "EOS R50"),
+			strings.HasPrefix(m, "EOS R100"), strings.HasPrefix(m, "EOS M"):
+			return 1.6
+		case strings.HasPrefix(m, "EOS R"), strings.HasPrefix(m, "EOS 5D"), strings.HasPrefix(m, "EOS 6D"),
+			strings.HasPrefix(m, "EOS-1D"), strings.HasPrefix(m, "EOS 1D"):
+			return 1
+		case strings.HasPrefix(m, "EOS ") && strings.HasSuffix(m, "D"): // 90D, 850D, ...
+			return 1.6
+		}
+	case "Nikon":
+		// DX bodies: D3xxx/D5xxx/D7xxx, D500, Z 50, Z 30, Z fc.
+		dx := len(m) == 5 && m[0] == 'D' && strings.ContainsRune("357", rune(m[1])) && strings.Trim(m[1:], "0123456789") == ""
+		switch {
+		case dx, m == "D500", strings.HasPrefix(m, "Z 50"), strings.HasPrefix(m, "Z 30"), strings.HasPrefix(m, "Z FC"):
+			return 1.5
+		case strings.HasPrefix(m, "Z "), strings.HasPrefix(m, "D"):
+			return 1
+		}
+	case "Leica":
+		if strings.HasPrefix(m, "M") || strings.HasPrefix(m, "Q") || strings.HasPrefix(m, "SL") {
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseExposure accepts "1/250", "0.004", "2" and "1/250 s".
+func parseExposure(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "s"))
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		d, err2 := strconv.ParseFloat(strings.TrimSpace(den), 64)
+		if err1 != nil || err2 != nil || d == 0 || n <= 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+func formatExposure(sec float64) string {
+	if sec >= 0.5 {
+		return trimFloat(sec, 1) + " s"
+	}
+	return fmt.Sprintf("1/%d s", int(math.Round(1/sec)))
+}
+
+func formatFocal(mm float64) string {
+	if mm >= 10 {
+		return strconv.Itoa(int(math.Round(mm))) + " mm"
+	}
+	return trimFloat(mm, 1) + " mm"
+}
+
+// trimFloat formats v with at most prec decimals and no trailing zeros.
+func trimFloat(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// Size returns the pixel size as displayed: EXIF orientations 5-8 rotate the
+// image a quarter turn, so width and height are swapped.
+func (it Item) Size() (int, int, bool) {
+	ex := it.Exif
+	if ex.ExifImageWidth == nil || ex.ExifImageHeight == nil {
+		return 0, 0, false
+	}
+	w, h := *ex.ExifImageWidth, *ex.ExifImageHeight
+	if ex.Orientation != nil {
+		if o, err := strconv.Atoi(strings.TrimSpace(*ex.Orientation)); err == nil && o >= 5 && o <= 8 {
+			w, h = h, w
+		}
+	}
+	return w, h, true
+}
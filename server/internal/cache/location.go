@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/geocode"
+	"github.com/ShinysArc/photography-portfolio/server/internal/immich"
+)
+
+type Location struct {
+	Lat     *float64 `json:"lat,omitempty"`
+	Lon     *float64 `json:"lon,omitempty"`
+	City    *string  `json:"city,omitempty"`
+	State   *string  `json:"state,omitempty"`
+	Country *string  `json:"country,omitempty"`
+}
+
+const kmPerDegree = 111.32
+
+// locationOf moves GPS and place fields out of ex and applies the
+// configured privacy policy to the coordinates.
+func locationOf(cfg config.Config, ex *immich.Exif) *Location {
+	loc := &Location{City: ex.City, State: ex.State, Country: ex.Country}
+	if ex.Latitude != nil && ex.Longitude != nil && !(*ex.Latitude == 0 && *ex.Longitude == 0) {
+		if loc.City == nil || loc.Country == nil {
+			fillPlace(cfg, loc, *ex.Latitude, *ex.Longitude)
+		}
+		loc.Lat, loc.Lon = applyGeoPrivacy(cfg, *ex.Latitude, *ex.Longitude)
+	}
+	ex.Latitude, ex.Longitude = nil, nil
+	ex.City, ex.State, ex.Country = nil, nil, nil
+
+	if loc.Lat == nil && loc.City == nil && loc.State == nil && loc.Country == nil {
+		return nil
+	}
+	return loc
+}
+
+var geocoder struct {
+	once sync.Once
+	g    *geocode.Geocoder
+}
+
+// fillPlace resolves missing place names offline from exact coordinates.
+func fillPlace(cfg config.Config, loc *Location, lat, lon float64) {
+	if !cfg.Geocode {
+		return
+	}
+	geocoder.once.Do(func() {
+		g, err := geocode.Load(cfg.GeocodeDir)
+		if err != nil {
+			log.Printf("geocode disabled: %v", err)
+			return
+		}
+		log.Printf("geocode: loaded %d places", g.Len())
+		geocoder.g = g
+	})
+
+	p, ok := geocoder.g.Lookup(lat, lon, cfg.GeocodeMaxKm)
+	if !ok {
+		return
+	}
+	set := func(dst **string, v string) {
+		if *dst == nil && v != "" {
+			*dst = &v
+		}
+	}
+	set(&loc.City, p.City)
+	set(&loc.State, p.Region)
+	set(&loc.Country, p.Country)
+}
+
+func applyGeoPrivacy(cfg config.Config, lat, lon float64) (*float64, *float64) {
+	switch strings.ToLower(cfg.GeoPrivacy) {
+	case "exact":
+		return &lat, &lon
+	case "drop":
+		return nil, nil
+	case "round", "":
+		km := cfg.GeoRoundKm
+		if !(km > 0) {
+			km = 5 // only GEO_PRIVACY=exact may publish exact points
+		}
+		lat, lon = roundCoords(lat, lon, km)
+		return &lat, &lon
+	default:
+		log.Printf("unknown GEO_PRIVACY %q, dropping coordinates", cfg.GeoPrivacy)
+		return nil, nil
+	}
+}
+
+// roundCoords snaps a point to the centre of a roughly km x km grid cell.
+func roundCoords(lat, lon, km float64) (float64, float64) {
+	latStep := km / kmPerDegree
+	rlat := (math.Floor(lat/latStep) + 0.5) * latStep
+	rlat = math.Max(-90, math.Min(90, rlat))
+
+	cos := math.Cos(rlat * math.Pi / 180)
+	if cos < 0.01 {
+		cos = 0.01
+	}
+	lonStep := km / (kmPerDegree * cos)
+	rlon := (math.Floor(lon/lonStep) + 0.5) * lonStep
+	rlon = math.Max(-180, math.Min(180, rlon))
+
+	const prec = 1e5
+	return math.Round(rlat*prec) / prec, math.Round(rlon*prec) / prec
+}
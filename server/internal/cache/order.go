@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/fsutil"
+)
+
+// Ordering policies for album items.
+const (
+	OrderAlbum       = "album" // as returned by Immich
+	OrderCaptureAsc  = "capture-asc"
+	OrderCaptureDesc = "capture-desc"
+	OrderFilename    = "filename"
+	OrderRating      = "rating"
+)
+
+var policies = []string{OrderAlbum, OrderCaptureAsc, OrderCaptureDesc, OrderFilename, OrderRating}
+
+// Ordering is the admin-editable curation for one album. Pinned items lead
+// in the given order, then Manual items, then the rest sorted by Policy.
+type Ordering struct {
+	Policy string   `json:"policy,omitempty"`
+	Pinned []string `json:"pinned,omitempty"`
+	Manual []string `json:"manual,omitempty"`
+}
+
+type orderFile struct {
+	Albums map[string]Ordering `json:"albums"`
+}
+
+var orderMu sync.Mutex
+
+func orderPath(cfg config.Config) string {
+	return filepath.Join(cfg.StateDir, "order.json")
+}
+
+func (o Ordering) Validate() error {
+	if o.Policy != "" && !slices.Contains(policies, o.Policy) {
+		return fmt.Errorf("unknown policy %q (want one of %s)", o.Policy, strings.Join(policies, ", "))
+	}
+	return nil
+}
+
+func readOrderFile(cfg config.Config) (orderFile, error) {
+	f := orderFile{Albums: map[string]Ordering{}}
+	b, err := os.ReadFile(orderPath(cfg))
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return f, err
+	}
+	if f.Albums == nil {
+		f.Albums = map[string]Ordering{}
+	}
+	return f, nil
+}
+
+// GetOrdering returns the curation for albumID, with the policy defaulted
+// from ALBUM_ORDER.
+func GetOrdering(cfg config.Config, albumID string) (Ordering, error) {
+	orderMu.Lock()
+	defer orderMu.Unlock()
+	f, err := readOrderFile(cfg)
+	if err != nil {
+		return Ordering{}, err
+	}
+	o := f.Albums[albumID]
+	if o.Policy == "" {
+		o.Policy = cfg.AlbumOrder
+	}
+	return o, nil
+}
+
+// SetOrdering stores the curation for albumID.
+func SetOrdering(cfg config.Config, albumID string, o Ordering) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	orderMu.Lock()
+	defer orderMu.Unlock()
+	f, err := readOrderFile(cfg)
+	if err != nil {
+		return err
+	}
+	f.Albums[albumID] = o
+	if err := EnsureDir(cfg); err != nil {
+		return err
+	}
+	b, _ := json.MarshalIndent(f, "", "  ")
+	return fsutil.WriteAtomic(orderPath(cfg), b, 0o600)
+}
+
+// applyOrdering sorts items in place.
+func applyOrdering(items []Item, o Ordering) {
+	rank := map[string]int{}
+	for i, id := range o.Pinned {
+		if _, ok := rank[id]; !ok {
+			rank[id] = i
+		}
+	}
+	for i, id := range o.Manual {
+		if _, ok := rank[id]; !ok {
+			rank[id] = len(o.Pinned) + i
+		}
+	}
+
+	byPolicy := func(a, b Item) int { return 0 }
+	switch o.Policy {
+	case OrderCaptureAsc:
+		byPolicy = func(a, b Item) int { return compareTaken(a, b) }
+	case OrderCaptureDesc:
+		byPolicy = func(a, b Item) int { return compareTaken(b, a) }
+	case OrderFilename:
+		byPolicy = func(a, b Item) int { return strings.Compare(fileName(a), fileName(b)) }
+	case OrderRating:
+		byPolicy = func(a, b Item) int {
+			return cmp.Or(cmp.Compare(rating(b), rating(a)), compareTaken(b, a))
+		}
+	}
+
+	slices.SortStableFunc(items, func(a, b Item) int {
+		ra, pa := rank[a.ID]
+		rb, pb := rank[b.ID]
+		switch {
+		case pa && pb:
+			return cmp.Compare(ra, rb)
+		case pa:
+			return -1
+		case pb:
+			return 1
+		}
+		return byPolicy(a, b)
+	})
+}
+
+// compareTaken orders by capture instant; undated items sort last.
+func compareTaken(a, b Item) int {
+	ta, oka := a.TakenAt()
+	tb, okb := b.TakenAt()
+	switch {
+	case oka && okb:
+		return ta.Compare(tb)
+	case oka:
+		return -1
+	case okb:
+		return 1
+	}
+	return 0
+}
+
+func fileName(it Item) string {
+	if it.OriginalFileName == nil {
+		return ""
+	}
+	return strings.ToLower(*it.OriginalFileName)
+}
+
+// Reorder re-applies the album's ordering to the staging file and
+// republishes, without going back to Immich.
+func Reorder(ctx context.Context, cfg config.Config) (File, error) {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	all, err := readStaging(cfg)
+	if err != nil {
+		return File{}, err
+	}
+	o, err := GetOrdering(cfg, all.Album.ID)
+	if err != nil {
+		return File{}, err
+	}
+	pos := make(map[string]int, len(all.AlbumOrder))
+	for i, id := range all.AlbumOrder {
+		pos[id] = i
+	}
+	slices.SortStableFunc(all.Items, func(a, b Item) int { return cmp.Compare(pos[a.ID], pos[b.ID]) })
+	applyOrdering(all.Items, o)
+
+	out, _ := json.MarshalIndent(all, "", "  ")
+	if err := fsutil.WriteAtomic(stagingPath(cfg), out, 0o600); err != nil {
+		return File{}, err
+	}
+	pub, next, err := publish(ctx, cfg, time.Now())
+	if err != nil {
+		return File{}, err
+	}
+	notifyScheduler(next)
+	return pub, nil
+}
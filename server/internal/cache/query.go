@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Query narrows and reorders the public cache for API consumers.
+type Query struct {
+	MinRating int    // keep items rated at least this many stars
+	Favorites bool   // keep favorites only
+	Sort      string // "" keeps cache order, "rating" sorts best rated first
+}
+
+func (q Query) IsZero() bool { return q == Query{} }
+
+func rating(it Item) int {
+	if it.Rating == nil {
+		return 0
+	}
+	return *it.Rating
+}
+
+// Score ranks items when picking showcase photos: favorites first, then by
+// rating.
+func (it Item) Score() int {
+	s := rating(it)
+	if it.Favorite {
+		s += 10
+	}
+	return s
+}
+
+// Apply returns a copy of f with q applied to its items.
+func (f File) Apply(q Query) File {
+	out := f
+	out.Items = make([]Item, 0, len(f.Items))
+	for _, it := range f.Items {
+		if q.MinRating > 0 && rating(it) < q.MinRating {
+			continue
+		}
+		if q.Favorites && !it.Favorite {
+			continue
+		}
+		out.Items = append(out.Items, it)
+	}
+	if q.Sort == "rating" {
+		slices.SortStableFunc(out.Items, func(a, b Item) int { return cmp.Compare(rating(b), rating(a)) })
+	}
+	return out
+}
+
+// featured picks up to limit favorite IDs, best rated first.
+func featured(items []Item, limit int) []string {
+	favs := make([]Item, 0)
+	for _, it := range items {
+		if it.Favorite {
+			favs = append(favs, it)
+		}
+	}
+	slices.SortStableFunc(favs, func(a, b Item) int { return cmp.Compare(rating(b), rating(a)) })
+	if limit > 0 && len(favs) > limit {
+		favs = favs[:limit]
+	}
+	ids := make([]string, len(favs))
+	for i, it := range favs {
+		ids[i] = it.ID
+	}
+	return ids
+}
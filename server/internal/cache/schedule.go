@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+// embargoes carries the next pending publish time from Refresh to the
+// scheduler; the zero time means nothing is scheduled.
+var embargoes = make(chan time.Time, 1)
+
+func notifyScheduler(next time.Time) {
+	select {
+	case <-embargoes: // drop a stale value nobody has read yet
+	default:
+	}
+	select {
+	case embargoes <- next:
+	default:
+	}
+}
+
+// RunScheduler re-emits cache.json whenever a scheduled item's embargo
+// expires. It blocks until ctx is done.
+func RunScheduler(ctx context.Context, cfg config.Config) {
+	var next time.Time
+	if _, n, err := Publish(ctx, cfg, time.Now()); err == nil {
+		next = n
+	}
+
+	for {
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = min(time.Until(next), time.Hour)
+		}
+		timer := time.NewTimer(max(wait, time.Second))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case next = <-embargoes:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		if next.IsZero() || time.Now().Before(next) {
+			continue
+		}
+		data, n, err := Publish(ctx, cfg, time.Now())
+		if err != nil {
+			log.Printf("scheduler: publish FAILED: %v", err)
+			next = time.Time{}
+			continue
+		}
+		log.Printf("scheduler: embargo expired, public items=%d", len(data.Items))
+		next = n
+	}
+}
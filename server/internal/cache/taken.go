@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TakenAt returns the capture instant from DateTimeOrig.
+func (it Item) TakenAt() (time.Time, bool) {
+	if it.Exif.DateTimeOrig == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, *it.Exif.DateTimeOrig)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// LocalTakenAt returns the capture time in the zone the photo was taken in,
+// falling back to UTC when TimeZone is missing or unknown. Refresh stores
+// the result in Taken so readers keep the original offset.
+func (it Item) LocalTakenAt() (time.Time, bool) {
+	if it.Taken != nil {
+		if t, err := time.Parse(time.RFC3339, *it.Taken); err == nil {
+			return t, true
+		}
+	}
+	t, ok := it.TakenAt()
+	if !ok {
+		return t, false
+	}
+	if it.Exif.TimeZone == nil {
+		return t, true
+	}
+	if loc := parseZone(*it.Exif.TimeZone); loc != nil {
+		return t.In(loc), true
+	}
+	return t, true
+}
+
+// parseZone accepts IANA names ("Europe/Paris") and the fixed offsets
+// Immich reports when EXIF has no zone name ("UTC+2", "UTC-05:30").
+func parseZone(s string) *time.Location {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(s, "UTC"); ok && rest != "" {
+		sign := 1
+		switch rest[0] {
+		case '+':
+		case '-':
+			sign = -1
+		default:
+			return nil
+		}
+		hh, mm, _ := strings.Cut(rest[1:], ":")
+		h, err := strconv.Atoi(hh)
+		if err != nil {
+			return nil
+		}
+		m := 0
+		if mm != "" {
+			if m, err = strconv.Atoi(mm); err != nil {
+				return nil
+			}
+		}
+		return time.FixedZone(s, sign*(h*3600+m*60))
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
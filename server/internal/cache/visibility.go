@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/immich"
+)
+
+/*
+Visibility markers, set either as Immich tags or as whole directive lines in
+the asset description:
+
+	publish:2026-11-01             hidden until that date (server local time)
+	publish:2026-11-01T18:00:00Z   hidden until that instant
+	unlisted                       never listed in cache.json
+
+A tag may be the bare marker or nested under a "visibility" parent
+("visibility/unlisted"). A description line must be the marker prefixed with
+"visibility:" or "#" ("visibility: unlisted", "#unlisted"); a bare
+"publish:<time>" line is also accepted since it cannot occur by accident.
+
+A publish marker whose time does not parse keeps the photo hidden, as if it
+were unlisted, and is reported by Check until it is fixed.
+*/
+
+type visibility struct {
+	PublishAt *time.Time
+	Unlisted  bool
+	Invalid   []string // publish markers with an unparseable time
+}
+
+// parseMarker reads a bare marker value.
+func parseMarker(s string, v *visibility) bool {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	switch {
+	case lower == "unlisted":
+		v.Unlisted = true
+		return true
+	case strings.HasPrefix(lower, "publish:"):
+		if t, ok := parsePublishTime(strings.TrimSpace(s[len("publish:"):])); ok {
+			v.PublishAt = &t
+		} else {
+			v.Unlisted = true
+			v.Invalid = append(v.Invalid, s)
+		}
+		return true
+	}
+	return false
+}
+
+// parseTag reads a marker tag: the bare value or "visibility/<value>".
+func parseTag(s string, v *visibility) bool {
+	s = strings.TrimSpace(s)
+	if len(s) > len("visibility/") && strings.EqualFold(s[:len("visibility/")], "visibility/") {
+		s = s[len("visibility/"):]
+	}
+	return parseMarker(s, v)
+}
+
+// parseDirective reads a description line that consists of a marker only.
+func parseDirective(line string, v *visibility) bool {
+	s := strings.TrimSpace(line)
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(lower, "#"):
+		s = s[1:]
+	case strings.HasPrefix(lower, "visibility:"):
+		s = s[len("visibility:"):]
+	case strings.HasPrefix(lower, "publish:"):
+	default:
+		return false
+	}
+	return parseMarker(s, v)
+}
+
+func parsePublishTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// visibilityOf reads markers from tags and the description. Marker tags and
+// directive lines are dropped from the returned values so they never reach
+// the public cache.
+func visibilityOf(tags []immich.Tag, description *string) (visibility, []immich.Tag, *string) {
+	var v visibility
+	kept := make([]immich.Tag, 0, len(tags))
+	for _, t := range tags {
+		// Value is the full path of a nested tag, Name only its leaf.
+		name := t.Value
+		if name == nil {
+			name = t.Name
+		}
+		if name == nil || !parseTag(*name, &v) {
+			kept = append(kept, t)
+		}
+	}
+	if description == nil {
+		return v, kept, nil
+	}
+	lines := strings.Split(*description, "\n")
+	text := lines[:0]
+	for _, line := range lines {
+		if !parseDirective(line, &v) {
+			text = append(text, line)
+		}
+	}
+	desc := strings.TrimSpace(strings.Join(text, "\n"))
+	if desc == "" {
+		return v, kept, nil
+	}
+	return v, kept, &desc
+}
+
+// Check returns human-readable warnings about visibility markers in the
+// staging file, for refresh output.
+func Check(cfg config.Config) []string {
+	all, err := readStaging(cfg)
+	if err != nil {
+		return []string{"staging: " + err.Error()}
+	}
+	var warns []string
+	for _, it := range all.Items {
+		for _, m := range it.InvalidMarkers {
+			warns = append(warns, fmt.Sprintf("photo %s: %q has no valid time, kept hidden", it.ID, m))
+		}
+	}
+	return warns
+}
+
+// isPublic reports whether an item belongs in the public cache at now.
+func (it Item) isPublic(now time.Time) bool {
+	if it.Unlisted {
+		return false
+	}
+	if it.PublishAt != nil {
+		if t, err := time.Parse(time.RFC3339, *it.PublishAt); err == nil && now.Before(t) {
+			return false
+		}
+	}
+	return true
+}
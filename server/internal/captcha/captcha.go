@@ -0,0 +1,113 @@
+// Package captcha verifies contact form challenges: Cloudflare Turnstile,
+// hCaptcha, or a proof-of-work puzzle issued by this server.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+const (
+	TurnstileURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	HCaptchaURL  = "https://api.hcaptcha.com/siteverify"
+)
+
+var (
+	ErrMissing     = errors.New("captcha required")
+	ErrRejected    = errors.New("captcha failed")
+	ErrUnavailable = errors.New("captcha verification unavailable")
+)
+
+// Verifier checks the token a client submitted with the form.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+	Provider() string
+}
+
+// New returns the verifier for cfg.Captcha, or nil when challenges are off.
+func New(cfg config.Config) (Verifier, error) {
+	switch cfg.Captcha {
+	case "", "off":
+		return nil, nil
+	case "turnstile", "hcaptcha":
+		// Without a site key the web app cannot render the widget, and every
+		// submission would fail as missing its token.
+		if cfg.CaptchaSecret == "" || cfg.CaptchaSiteKey == "" {
+			return nil, fmt.Errorf("CAPTCHA=%s needs CAPTCHA_SECRET and CAPTCHA_SITE_KEY", cfg.Captcha)
+		}
+		u := cfg.CaptchaVerifyURL
+		if u == "" {
+			u = TurnstileURL
+			if cfg.Captcha == "hcaptcha" {
+				u = HCaptchaURL
+			}
+		}
+		return &siteverify{
+			provider: cfg.Captcha,
+			url:      u,
+			secret:   cfg.CaptchaSecret,
+			siteKey:  cfg.CaptchaSiteKey,
+			client:   &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	case "pow":
+		return NewPoW(cfg.CaptchaSecret, cfg.CaptchaDifficulty), nil
+	default:
+		return nil, fmt.Errorf("unknown CAPTCHA %q (turnstile, hcaptcha, pow, off)", cfg.Captcha)
+	}
+}
+
+// siteverify implements the siteverify API shared by Turnstile and hCaptcha.
+type siteverify struct {
+	provider string
+	url      string
+	secret   string
+	siteKey  string
+	client   *http.Client
+}
+
+func (s *siteverify) Provider() string { return s.provider }
+
+func (s *siteverify) Verify(ctx context.Context, token, remoteIP string) error {
+	if strings.TrimSpace(token) == "" {
+		return ErrMissing
+	}
+	form := url.Values{"secret": {s.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	if s.siteKey != "" && s.provider == "hcaptcha" {
+		form.Set("sitekey", s.siteKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %s", ErrUnavailable, s.provider, res.Status)
+	}
+	var out struct {
+		Success bool     `json:"success"`
+		Errors  []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if !out.Success {
+		return fmt.Errorf("%w: %s", ErrRejected, strings.Join(out.Errors, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+// standIn fakes a siteverify endpoint and records the last form it received.
+func standIn(t *testing.T, status int, body string) (*httptest.Server, func() url.Values) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		last url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		mu.Lock()
+		last = r.PostForm
+		mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return last
+	}
+}
+
+func newVerifier(t *testing.T, provider, endpoint string) Verifier {
+	t.Helper()
+	v, err := New(config.Config{Captcha: provider, CaptchaSecret: "s3cret", CaptchaSiteKey: "site", CaptchaVerifyURL: endpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestSiteverify(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"success", http.StatusOK, `{"success":true}`, nil},
+		{"rejected", http.StatusOK, `{"success":false,"error-codes":["invalid-input-response"]}`, ErrRejected},
+		{"server error", http.StatusInternalServerError, `oops`, ErrUnavailable},
+		{"bad json", http.StatusOK, `<html>`, ErrUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, last := standIn(t, tc.status, tc.body)
+			v := newVerifier(t, "hcaptcha", srv.URL)
+
+			err := v.Verify(context.Background(), "token", "203.0.113.7")
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("Verify = %v, want %v", err, tc.want)
+			}
+			for k, want := range map[string]string{"secret": "s3cret", "response": "token", "remoteip": "203.0.113.7", "sitekey": "site"} {
+				if got := last().Get(k); got != want {
+					t.Errorf("form %s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSiteverifyUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	endpoint := srv.URL
+	srv.Close()
+
+	v := newVerifier(t, "turnstile", endpoint)
+	if err := v.Verify(context.Background(), "token", ""); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Verify = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestSiteverifyMissingToken(t *testing.T) {
+	srv, _ := standIn(t, http.StatusOK, `{"success":true}`)
+	v := newVerifier(t, "turnstile", srv.URL)
+	if err := v.Verify(context.Background(), " ", ""); !errors.Is(err, ErrMissing) {
+		t.Fatalf("Verify = %v, want ErrMissing", err)
+	}
+}
+
+func TestNewRequiresKeys(t *testing.T) {
+	for _, cfg := range []config.Config{
+		{Captcha: "turnstile", CaptchaSecret: "s"},
+		{Captcha: "hcaptcha", CaptchaSiteKey: "k"},
+		{Captcha: "recaptcha", CaptchaSecret: "s", CaptchaSiteKey: "k"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded", cfg)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package captcha
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// powTTL is how long an issued challenge can be solved and redeemed.
+const powTTL = 10 * time.Minute
+
+// PoW issues stateless, HMAC-signed challenges. A client solves one by
+// finding a nonce such that sha256(challenge + ":" + nonce) starts with
+// Bits zero bits, and submits "challenge:nonce" as the token. Redeemed
+// challenges are remembered until they expire, so each works once.
+type PoW struct {
+	Bits   int
+	secret []byte
+
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+// NewPoW signs with secret, or with a random per-process key when empty
+// (outstanding challenges then stop working on restart).
+func NewPoW(secret string, bits int) *PoW {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &PoW{Bits: bits, secret: key, used: map[string]time.Time{}}
+}
+
+func (p *PoW) Provider() string { return "pow" }
+
+// Challenge is what GET /api/captcha hands to the client.
+type Challenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"` // leading zero bits
+	Expires    time.Time `json:"expires"`
+}
+
+// Issue creates a challenge valid for powTTL.
+func (p *PoW) Issue(now time.Time) (Challenge, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return Challenge{}, err
+	}
+	exp := now.Add(powTTL).Truncate(time.Second)
+	payload := strconv.FormatInt(exp.Unix(), 10) + "." + strconv.Itoa(p.Bits) + "." + base64.RawURLEncoding.EncodeToString(b[:])
+	return Challenge{
+		Challenge:  payload + "." + base64.RawURLEncoding.EncodeToString(p.mac(payload)),
+		Difficulty: p.Bits,
+		Expires:    exp.UTC(),
+	}, nil
+}
+
+func (p *PoW) Verify(_ context.Context, token, _ string) error {
+	return p.verify(token, time.Now())
+}
+
+func (p *PoW) verify(token string, now time.Time) error {
+	if strings.TrimSpace(token) == "" {
+		return ErrMissing
+	}
+	challenge, nonce, ok := strings.Cut(token, ":")
+	if !ok || nonce == "" || len(nonce) > 64 {
+		return fmt.Errorf("%w: malformed token", ErrRejected)
+	}
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return fmt.Errorf("%w: malformed challenge", ErrRejected)
+	}
+	payload := strings.Join(parts[:3], ".")
+	sig, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil || !hmac.Equal(sig, p.mac(payload)) {
+		return fmt.Errorf("%w: bad signature", ErrRejected)
+	}
+	exp, err1 := strconv.ParseInt(parts[0], 10, 64)
+	difficulty, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf("%w: malformed challenge", ErrRejected)
+	}
+	if now.Unix() > exp {
+		return fmt.Errorf("%w: challenge expired", ErrRejected)
+	}
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	if leadingZeros(sum[:]) < difficulty {
+		return fmt.Errorf("%w: insufficient work", ErrRejected)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for c, t := range p.used {
+		if now.After(t) {
+			delete(p.used, c)
+		}
+	}
+	if _, seen := p.used[challenge]; seen {
+		return fmt.Errorf("%w: challenge already used", ErrRejected)
+	}
+	p.used[challenge] = time.Unix(exp, 0)
+	return nil
+}
+
+func (p *PoW) mac(payload string) []byte {
+	h := hmac.New(sha256.New, p.secret)
+	h.Write([]byte("pow:v1:" + payload))
+	return h.Sum(nil)
+}
+
+func leadingZeros(b []byte) int {
+	n := 0
+	for len(b) >= 8 {
+		v := binary.BigEndian.Uint64(b)
+		if v != 0 {
+			return n + bits.LeadingZeros64(v)
+		}
+		n += 64
+		b = b[8:]
+	}
+	return n
+}
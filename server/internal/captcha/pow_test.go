@@ -0,0 +1,96 @@
+package captcha
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// solve brute-forces a nonce for c.
+func solve(t *testing.T, c Challenge) string {
+	t.Helper()
+	for n := 0; n < 1<<24; n++ {
+		nonce := strconv.Itoa(n)
+		sum := sha256.Sum256([]byte(c.Challenge + ":" + nonce))
+		if leadingZeros(sum[:]) >= c.Difficulty {
+			return c.Challenge + ":" + nonce
+		}
+	}
+	t.Fatal("no nonce found")
+	return ""
+}
+
+func issue(t *testing.T, p *PoW, now time.Time) Challenge {
+	t.Helper()
+	c, err := p.Issue(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPoWSolvedOnce(t *testing.T) {
+	p := NewPoW("secret", 8)
+	now := time.Now()
+	token := solve(t, issue(t, p, now))
+
+	if err := p.verify(token, now); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := p.verify(token, now.Add(time.Second)); !errors.Is(err, ErrRejected) {
+		t.Fatalf("replay: %v, want ErrRejected", err)
+	}
+}
+
+func TestPoWExpires(t *testing.T) {
+	p := NewPoW("secret", 8)
+	now := time.Now()
+	token := solve(t, issue(t, p, now))
+
+	if err := p.verify(token, now.Add(powTTL+time.Second)); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expired: %v, want ErrRejected", err)
+	}
+}
+
+func TestPoWDifficulty(t *testing.T) {
+	p := NewPoW("secret", 12)
+	c := issue(t, p, time.Now())
+
+	// Find a nonce that falls short of the required work.
+	var weak string
+	for n := 0; ; n++ {
+		nonce := strconv.Itoa(n)
+		sum := sha256.Sum256([]byte(c.Challenge + ":" + nonce))
+		if leadingZeros(sum[:]) < c.Difficulty {
+			weak = c.Challenge + ":" + nonce
+			break
+		}
+	}
+	if err := p.verify(weak, time.Now()); !errors.Is(err, ErrRejected) {
+		t.Fatalf("weak nonce: %v, want ErrRejected", err)
+	}
+
+	// Lowering the difficulty inside the challenge breaks its signature.
+	parts := strings.Split(c.Challenge, ".")
+	parts[1] = "1"
+	forged := Challenge{Challenge: strings.Join(parts, "."), Difficulty: 1}
+	if err := p.verify(solve(t, forged), time.Now()); !errors.Is(err, ErrRejected) {
+		t.Fatalf("forged difficulty: %v, want ErrRejected", err)
+	}
+}
+
+func TestPoWOtherKey(t *testing.T) {
+	token := solve(t, issue(t, NewPoW("one", 4), time.Now()))
+	if err := NewPoW("two", 4).verify(token, time.Now()); !errors.Is(err, ErrRejected) {
+		t.Fatalf("foreign challenge: %v, want ErrRejected", err)
+	}
+}
+
+func TestPoWMissing(t *testing.T) {
+	if err := NewPoW("", 4).verify("", time.Now()); !errors.Is(err, ErrMissing) {
+		t.Fatalf("empty token: %v, want ErrMissing", err)
+	}
+}
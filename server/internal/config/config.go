@@ -2,20 +2,46 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	Port        string
 	DataDir     string
+	StateDir    string // private state, never served publicly
+	ContentDir  string // hand-edited content files (gear.json, ...)
 	AllowOrigin string
 
+	PublicURL  string // site origin used in feeds, sitemaps and embeds
+	SiteTitle  string
+	AuthorName string
+
 	ImmichURL     string
 	ImmichAPIKey  string
 	ImmichAlbumID string
 
-	AdminToken string
+	AdminToken  string
+	EmbedSecret string // HMAC key for gallery widget tokens; embeds are off when empty
+
+	AlbumOrder  string // default ordering policy, see cache.Ordering
+	FeaturedMax int
+	ExifAliases string // optional JSON alias table for camera/lens names
+
+	GeoPrivacy string  // "drop", "round" or "exact"
+	GeoRoundKm float64 // grid size when GeoPrivacy is "round"
+
+	Geocode      bool    // offline reverse geocoding for unresolved places
+	GeocodeDir   string  // GeoNames dump directory; empty uses the bundled subset
+	GeocodeMaxKm float64 // ignore places farther than this
+
+	MailTransport string // "smtp", "file" (Maildir) or "log" (stdout)
+	MailDir       string // Maildir for the file transport
 
 	SMTPHost    string
 	SMTPPort    int
@@ -23,6 +49,32 @@ type Config struct {
 	SMTPPass    string
 	ContactFrom string
 	ContactTo   string
+
+	SpamThreshold int    // contact messages scoring this much are quarantined
+	SpamKeywords  string // extra comma-separated spam phrases
+	SpamBlocklist string // extra comma-separated emails, @domains, IPs or CIDRs
+
+	Captcha           string // "turnstile", "hcaptcha", "pow" or "" (off)
+	CaptchaSecret     string // provider secret, or the HMAC key for pow
+	CaptchaSiteKey    string // public key handed to the web app
+	CaptchaVerifyURL  string // overrides the provider siteverify URL
+	CaptchaDifficulty int    // leading zero bits required by pow
+
+	RateLimits       string // per-client limits, see middleware.ParseRateLimits
+	RateLimitsGlobal string // per-route limits shared by all clients
+	TrustedProxies   string // IPs/CIDRs whose X-Forwarded-For is believed, or "none"
+
+	ContactMaxFiles    int    // attachments per contact message
+	ContactMaxBytes    int64  // total attachment size per message
+	ContactAttachTypes string // comma-separated MIME types, matched against sniffed content
+
+	AutoReply       bool          // confirm receipt to the visitor
+	AutoReplyWindow time.Duration // at most one auto-reply per address in this period
+
+	MailMaxAttempts int // deliveries tried before a queued message is dead-lettered
+
+	InboxRetentionDays int // stored contact messages are purged after this; 0 keeps them
+	InboxSpamDays      int // same for messages marked spam
 }
 
 func getenv(k, def string) string {
@@ -43,17 +95,64 @@ func mustenv(k string) string {
 func Load() Config {
 	p := getenv("PORT", "8080")
 	sp, _ := strconv.Atoi(getenv("SMTP_PORT", "587"))
+	fm, _ := strconv.Atoi(getenv("FEATURED_MAX", "12"))
+	gk, err := strconv.ParseFloat(getenv("GEO_ROUND_KM", "5"), 64)
+	if err != nil || !(gk > 0) || math.IsInf(gk, 0) {
+		// Never fall back to exact coordinates; that takes GEO_PRIVACY=exact.
+		log.Printf("GEO_ROUND_KM %q is not a positive number of km, using 5", os.Getenv("GEO_ROUND_KM"))
+		gk = 5
+	}
+	gmk, _ := strconv.ParseFloat(getenv("GEOCODE_MAX_KM", "50"), 64)
+	// The bundled dataset only has major cities, too coarse to label photos
+	// by default; it takes an explicit GEOCODE=on.
+	geocodeDir := getenv("GEOCODE_DIR", "")
+	geocodeDefault := "off"
+	if geocodeDir != "" {
+		geocodeDefault = "on"
+	}
+	mma, _ := strconv.Atoi(getenv("MAIL_MAX_ATTEMPTS", "10"))
+	ird, _ := strconv.Atoi(getenv("INBOX_RETENTION_DAYS", "365"))
+	isd, _ := strconv.Atoi(getenv("INBOX_SPAM_RETENTION_DAYS", "30"))
+	spt, _ := strconv.Atoi(getenv("SPAM_THRESHOLD", "5"))
+	cd, _ := strconv.Atoi(getenv("CAPTCHA_DIFFICULTY", "16"))
+	cmf, _ := strconv.Atoi(getenv("CONTACT_MAX_FILES", "5"))
+	cmb, _ := strconv.ParseInt(getenv("CONTACT_MAX_BYTES", "10485760"), 10, 64)
+	arw, err := time.ParseDuration(getenv("AUTOREPLY_WINDOW", "24h"))
+	if err != nil || arw <= 0 {
+		arw = 24 * time.Hour
+	}
 
 	return Config{
 		Port:        p,
 		DataDir:     getenv("DATA_DIR", "data"),
+		StateDir:    getenv("STATE_DIR", "state"),
+		ContentDir:  getenv("CONTENT_DIR", "content"),
 		AllowOrigin: getenv("ALLOW_ORIGIN", "*"),
 
+		PublicURL:  strings.TrimRight(getenv("PUBLIC_URL", "http://localhost:3000"), "/"),
+		SiteTitle:  getenv("SITE_TITLE", "Photography Portfolio"),
+		AuthorName: getenv("AUTHOR_NAME", ""),
+
 		ImmichURL:     mustenv("IMMICH_URL"),
 		ImmichAPIKey:  mustenv("IMMICH_API_KEY"),
 		ImmichAlbumID: mustenv("IMMICH_ALBUM_ID"),
 
-		AdminToken: mustenv("ADMIN_TOKEN"),
+		AdminToken:  mustenv("ADMIN_TOKEN"),
+		EmbedSecret: getenv("EMBED_SECRET", ""),
+
+		AlbumOrder:  getenv("ALBUM_ORDER", "capture-desc"),
+		FeaturedMax: fm,
+		ExifAliases: getenv("EXIF_ALIASES", ""),
+
+		GeoPrivacy: getenv("GEO_PRIVACY", "round"),
+		GeoRoundKm: gk,
+
+		Geocode:      getenv("GEOCODE", geocodeDefault) != "off",
+		GeocodeDir:   geocodeDir,
+		GeocodeMaxKm: gmk,
+
+		MailTransport: getenv("MAIL_TRANSPORT", "smtp"),
+		MailDir:       getenv("MAIL_DIR", filepath.Join(getenv("STATE_DIR", "state"), "maildir")),
 
 		SMTPHost:    getenv("SMTP_HOST", ""),
 		SMTPPort:    sp,
@@ -61,5 +160,31 @@ func Load() Config {
 		SMTPPass:    getenv("SMTP_PASS", ""),
 		ContactFrom: getenv("CONTACT_FROM", ""),
 		ContactTo:   getenv("CONTACT_TO", ""),
+
+		SpamThreshold: max(spt, 1),
+		SpamKeywords:  getenv("SPAM_KEYWORDS", ""),
+		SpamBlocklist: getenv("SPAM_BLOCKLIST", ""),
+
+		Captcha:           getenv("CAPTCHA", ""),
+		CaptchaSecret:     getenv("CAPTCHA_SECRET", ""),
+		CaptchaSiteKey:    getenv("CAPTCHA_SITE_KEY", ""),
+		CaptchaVerifyURL:  getenv("CAPTCHA_VERIFY_URL", ""),
+		CaptchaDifficulty: min(max(cd, 1), 32),
+
+		RateLimits:       getenv("RATE_LIMITS", "POST /api/contact=5/10m, POST /api/refresh=6/m, GET /api/captcha=30/10m, /healthz=off, *=600/m"),
+		RateLimitsGlobal: getenv("RATE_LIMITS_GLOBAL", ""),
+		TrustedProxies:   getenv("TRUSTED_PROXIES", "127.0.0.0/8,::1,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7"),
+
+		ContactMaxFiles:    max(cmf, 0),
+		ContactMaxBytes:    max(cmb, 0),
+		ContactAttachTypes: getenv("CONTACT_ATTACH_TYPES", "image/jpeg,image/png,image/webp,image/gif,application/pdf"),
+
+		AutoReply:       getenv("AUTOREPLY", "off") == "on",
+		AutoReplyWindow: arw,
+
+		MailMaxAttempts: max(mma, 1),
+
+		InboxRetentionDays: max(ird, 0),
+		InboxSpamDays:      max(isd, 0),
 	}
 }
@@ -0,0 +1,88 @@
+// Package contact holds the checks applied to contact form submissions.
+package contact
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+// reservedSuffixes (and their subdomains) never receive mail (RFC 2606, RFC 6761) or only exist on
+// local networks.
+var reservedSuffixes = []string{
+	"test", "example", "invalid", "localhost", "local", "internal",
+	"example.com", "example.net", "example.org",
+}
+
+// typoDomains are common misspellings of large providers; mail to them
+// bounces or reaches a squatter.
+var typoDomains = map[string]bool{
+	"gmial.com": true, "gmai.com": true, "gnail.com": true, "gmaill.com": true, "gamil.com": true,
+	"hotmial.com": true, "hotmai.com": true, "homail.com": true,
+	"yahooo.com": true, "yaho.com": true,
+	"outlok.com": true, "outllok.com": true,
+	"iclod.com": true, "icoud.com": true,
+}
+
+// typoTLDs are mistyped endings of common TLDs.
+var typoTLDs = map[string]bool{"con": true, "cmo": true, "ocm": true, "comm": true, "vom": true, "xom": true, "nte": true, "ogr": true}
+
+// CheckAddress reports why s is not a plausible recipient. It checks syntax
+// and shape only and never touches the network, so a nil result does not
+// prove the mailbox exists.
+func CheckAddress(s string) error {
+	s = strings.TrimSpace(s)
+	a, err := mail.ParseAddress(s)
+	if err != nil || a.Address != s || a.Name != "" {
+		return errors.New("not a bare email address")
+	}
+	if len(s) > 254 {
+		return errors.New("address too long")
+	}
+	local, domain, _ := strings.Cut(s, "@")
+	if local == "" || len(local) > 64 || strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") || strings.HasPrefix(local, `"`) {
+		return errors.New("unusual local part")
+	}
+
+	domain = strings.ToLower(domain)
+	if strings.HasPrefix(domain, "[") {
+		return errors.New("address literal domain")
+	}
+	if len(domain) > 253 {
+		return errors.New("domain too long")
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return errors.New("domain has no TLD")
+	}
+	for _, l := range labels {
+		if !validLabel(l) {
+			return errors.New("invalid domain label")
+		}
+	}
+	tld := labels[len(labels)-1]
+	if !strings.HasPrefix(tld, "xn--") && (len(tld) < 2 || strings.Trim(tld, "abcdefghijklmnopqrstuvwxyz") != "") {
+		return errors.New("invalid TLD")
+	}
+	if typoTLDs[tld] || typoDomains[domain] {
+		return errors.New("likely misspelled domain")
+	}
+	for _, r := range reservedSuffixes {
+		if domain == r || strings.HasSuffix(domain, "."+r) {
+			return errors.New("reserved domain")
+		}
+	}
+	return nil
+}
+
+func validLabel(l string) bool {
+	if l == "" || len(l) > 63 || l[0] == '-' || l[len(l)-1] == '-' {
+		return false
+	}
+	for _, c := range l {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package contact
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckAddress(t *testing.T) {
+	for _, tc := range []struct {
+		addr string
+		ok   bool
+	}{
+		{"jane@photos.fr", true},
+		{"  jane.doe+prints@mail.co.uk ", true},
+		{"j@xn--bcher-kva.ch", true},
+		{"Jane <jane@photos.fr>", false},
+		{"jane", false},
+		{"jane@localhost", false},
+		{".jane@photos.fr", false},
+		{"ja..ne@photos.fr", false},
+		{`"jane"@photos.fr`, false},
+		{strings.Repeat("a", 65) + "@photos.fr", false},
+		{"jane@[192.0.2.1]", false},
+		{"jane@-photos.fr", false},
+		{"jane@photos_fr.com", false},
+		{"jane@photos.f", false},
+		{"jane@photos.123", false},
+		{"jane@gmial.com", false},
+		{"jane@photos.con", false},
+		{"jane@example.com", false},
+		{"jane@shop.example", false},
+		{"jane@printer.local", false},
+	} {
+		if err := CheckAddress(tc.addr); (err == nil) != tc.ok {
+			t.Errorf("CheckAddress(%q) = %v, want ok=%v", tc.addr, err, tc.ok)
+		}
+	}
+}
+
+func TestWindowForget(t *testing.T) {
+	w := NewWindow(time.Minute)
+	now := time.Now()
+	if !w.Allow("k", now) || w.Allow("k", now) {
+		t.Fatal("second Allow within the period should be refused")
+	}
+	w.Forget("k")
+	if !w.Allow("k", now) {
+		t.Error("Allow after Forget refused")
+	}
+	if !w.Allow("k", now.Add(time.Minute)) {
+		t.Error("Allow after the period refused")
+	}
+}
@@ -0,0 +1,232 @@
+package contact
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+// minFillTime is the fastest a human fills the form.
+const minFillTime = 3 * time.Second
+
+// unstamped is added when the form stamp is missing, forged or expired. It
+// stays below the default threshold on its own, so a restart that voids open
+// forms' stamps does not quarantine everyone.
+const unstamped = 3
+
+// blocked is added to the score of blocklisted senders; no message outweighs it.
+const blocked = 100
+
+// builtinKeywords are phrases seen almost only in contact-form spam.
+var builtinKeywords = []string{
+	"seo", "backlink", "casino", "viagra", "cialis", "crypto", "bitcoin", "forex",
+	"loan", "escort", "porn", "web design services", "guest post", "rank your website",
+	"first page of google", "increase your traffic", "unsubscribe",
+}
+
+var linkRE = regexp.MustCompile(`(?i)https?://|www\.|\[url=`)
+
+// Submission is what the checks see of a contact request.
+type Submission struct {
+	Name      string
+	Email     string
+	Subject   string
+	Message   string
+	IP        string
+	Honeypot  string
+	StartedAt *time.Time // when the form was served, from a verified server stamp
+	ClientAt  *time.Time // the client's own start time; only ever used to drop
+}
+
+// Verdict is the outcome of Check. Drop means a bot was caught outright and
+// the submission should be acknowledged but neither stored nor mailed.
+type Verdict struct {
+	Drop    bool     `json:"-"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+func (v *Verdict) add(points int, reason string) {
+	v.Score += points
+	v.Reasons = append(v.Reasons, reason+" (+"+strconv.Itoa(points)+")")
+}
+
+// Spam reports whether the score reaches cfg.SpamThreshold.
+func (v Verdict) Spam(cfg config.Config) bool {
+	return v.Score >= cfg.SpamThreshold
+}
+
+// Check runs the spam pipeline on s.
+func Check(cfg config.Config, s Submission, now time.Time) Verdict {
+	var v Verdict
+	if strings.TrimSpace(s.Honeypot) != "" {
+		v.Drop = true
+		v.Reasons = []string{"honeypot filled"}
+		return v
+	}
+	// The signed stamp times the fill on our clock. The client's clock can
+	// be anything a bot likes, so it may catch a fast one but never vouches
+	// for a submission; a negative fill time there is just a clock ahead.
+	for _, at := range []*time.Time{s.StartedAt, s.ClientAt} {
+		if at == nil {
+			continue
+		}
+		if d := now.Sub(*at); d >= 0 && d < minFillTime {
+			v.Drop = true
+			v.Reasons = []string{"submitted too fast"}
+			return v
+		}
+	}
+	if s.StartedAt == nil {
+		v.add(unstamped, "no valid form stamp")
+	}
+
+	if entry, ok := loadBlocklist(cfg).match(s.Email, s.IP); ok {
+		v.add(blocked, "blocklisted "+entry)
+	}
+
+	if n := len(linkRE.FindAllString(s.Name, -1)); n > 0 {
+		v.add(5, "link in name")
+	}
+	if n := len(linkRE.FindAllString(s.Subject+"\n"+s.Message, -1)); n > 1 {
+		v.add(2*(n-1), strconv.Itoa(n)+" links")
+	}
+
+	text := strings.ToLower(s.Subject + "\n" + s.Message)
+	for _, kw := range keywords(cfg) {
+		if containsWord(text, kw) {
+			v.add(2, "keyword "+strconv.Quote(kw))
+		}
+	}
+
+	if shouting(s.Message) {
+		v.add(1, "mostly capitals")
+	}
+	return v
+}
+
+func keywords(cfg config.Config) []string {
+	out := builtinKeywords
+	for _, k := range strings.Split(cfg.SpamKeywords, ",") {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			out = append(out[:len(out):len(out)], k)
+		}
+	}
+	return out
+}
+
+// containsWord matches kw at word boundaries, so "seo" does not hit "seoul";
+// a plural "s" is allowed.
+func containsWord(text, kw string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], kw)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(kw)
+		if end < len(text) && text[end] == 's' {
+			end++
+		}
+		if (start == 0 || !isWordByte(text[start-1])) && (end == len(text) || !isWordByte(text[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b >= 0x80
+}
+
+func shouting(s string) bool {
+	var letters, upper int
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 20 && upper*10 >= letters*7
+}
+
+// blocklist holds exact addresses, domains (with subdomains) and IP prefixes.
+type blocklist struct {
+	emails   map[string]bool
+	domains  []string
+	prefixes []netip.Prefix
+}
+
+// loadBlocklist merges CONTENT_DIR/blocklist.txt (one entry per line, "#"
+// comments) with the comma-separated SPAM_BLOCKLIST.
+func loadBlocklist(cfg config.Config) blocklist {
+	b := blocklist{emails: map[string]bool{}}
+	for _, e := range strings.Split(cfg.SpamBlocklist, ",") {
+		b.add(e)
+	}
+	f, err := os.Open(filepath.Join(cfg.ContentDir, "blocklist.txt"))
+	if err != nil {
+		return b
+	}
+	defer func() { _ = f.Close() }()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		b.add(line)
+	}
+	return b
+}
+
+func (b *blocklist) add(e string) {
+	e = strings.ToLower(strings.TrimSpace(e))
+	switch {
+	case e == "":
+	case strings.Contains(e, "/"):
+		if p, err := netip.ParsePrefix(e); err == nil {
+			b.prefixes = append(b.prefixes, p.Masked())
+		}
+	case strings.HasPrefix(e, "@"):
+		b.domains = append(b.domains, e[1:])
+	case strings.Contains(e, "@"):
+		b.emails[e] = true
+	default:
+		if a, err := netip.ParseAddr(e); err == nil {
+			b.prefixes = append(b.prefixes, netip.PrefixFrom(a, a.BitLen()))
+		} else {
+			b.domains = append(b.domains, e)
+		}
+	}
+}
+
+// match returns the entry that blocks email or ip.
+func (b blocklist) match(email, ip string) (string, bool) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if b.emails[email] {
+		return "address", true
+	}
+	if _, domain, ok := strings.Cut(email, "@"); ok {
+		for _, d := range b.domains {
+			if domain == d || strings.HasSuffix(domain, "."+d) {
+				return "domain " + d, true
+			}
+		}
+	}
+	if a, err := netip.ParseAddr(ip); err == nil {
+		a = a.Unmap()
+		for _, p := range b.prefixes {
+			if p.Contains(a) {
+				return "ip " + p.String(), true
+			}
+		}
+	}
+	return "", false
+}
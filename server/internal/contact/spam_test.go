@@ -0,0 +1,74 @@
+package contact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time { t := now.Add(-d); return &t }
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "blocklist.txt"), []byte("# spammers\n@spam.io\n203.0.113.0/24\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{ContentDir: dir, SpamBlocklist: "bad@example.org", SpamKeywords: "Telegram", SpamThreshold: 5}
+
+	base := Submission{Name: "Jane", Email: "jane@photos.fr", Subject: "Print", Message: "Is the heron photo available as a print?", IP: "198.51.100.4", StartedAt: ago(time.Minute)}
+	for _, tc := range []struct {
+		name  string
+		edit  func(*Submission)
+		drop  bool
+		score int
+	}{
+		{"clean", func(*Submission) {}, false, 0},
+		{"honeypot", func(s *Submission) { s.Honeypot = "x" }, true, 0},
+		{"stamped too fast", func(s *Submission) { s.StartedAt = ago(time.Second) }, true, 0},
+		{"client too fast", func(s *Submission) { s.ClientAt = ago(time.Second) }, true, 0},
+		{"client clock ahead", func(s *Submission) { s.ClientAt = ago(-time.Hour) }, false, 0},
+		{"no stamp", func(s *Submission) { s.StartedAt = nil }, false, unstamped},
+		{"no stamp, slow client", func(s *Submission) { s.StartedAt, s.ClientAt = nil, ago(time.Hour) }, false, unstamped},
+		{"blocked address", func(s *Submission) { s.Email = "Bad@Example.org" }, false, blocked},
+		{"blocked subdomain", func(s *Submission) { s.Email = "a@mx.spam.io" }, false, blocked},
+		{"blocked range", func(s *Submission) { s.IP = "203.0.113.77" }, false, blocked},
+		{"link in name", func(s *Submission) { s.Name = "www.cheap.biz" }, false, 5},
+		{"one link", func(s *Submission) { s.Message = "See https://photos.fr/a" }, false, 0},
+		{"three links", func(s *Submission) { s.Message = "https://a.biz https://b.biz www.c.biz" }, false, 4},
+		{"keywords", func(s *Submission) { s.Message = "Cheap backlinks, message me on telegram" }, false, 4},
+		{"keyword inside a word", func(s *Submission) { s.Message = "Shot in Seoul last spring" }, false, 0},
+		{"shouting", func(s *Submission) { s.Message = strings.ToUpper(base.Message) }, false, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := base
+			tc.edit(&s)
+			v := Check(cfg, s, now)
+			if v.Drop != tc.drop || v.Score != tc.score {
+				t.Errorf("Check = drop %v score %d %v, want drop %v score %d", v.Drop, v.Score, v.Reasons, tc.drop, tc.score)
+			}
+		})
+	}
+}
+
+func TestStamp(t *testing.T) {
+	s, now := NewStamper(), time.Now()
+	stamp := s.Issue(now)
+	if at, ok := s.Verify(stamp, now.Add(time.Minute)); !ok || at.UnixMilli() != now.UnixMilli() {
+		t.Errorf("Verify = %v, %v", at, ok)
+	}
+	if _, ok := s.Verify(stamp, now.Add(StampTTL+time.Second)); ok {
+		t.Error("expired stamp accepted")
+	}
+	if _, ok := NewStamper().Verify(stamp, now); ok {
+		t.Error("stamp from another key accepted")
+	}
+	ts, mac, _ := strings.Cut(stamp, ".")
+	if _, ok := s.Verify(ts+"1."+mac, now); ok {
+		t.Error("altered stamp accepted")
+	}
+}
@@ -0,0 +1,59 @@
+package contact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stamper signs the time the contact form was served, so the fill-time check
+// runs on the server clock instead of the visitor's. The key is random per
+// process: a stamp issued before a restart no longer verifies and the
+// submission is scored as unstamped.
+// StampTTL is how long a stamp stays valid; a form left open longer is
+// treated as unstamped.
+const StampTTL = 24 * time.Hour
+
+type Stamper struct {
+	key []byte
+}
+
+func NewStamper() *Stamper {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return &Stamper{key: key}
+}
+
+// Issue returns "<unix ms>.<mac>" for now.
+func (s *Stamper) Issue(now time.Time) string {
+	ts := strconv.FormatInt(now.UnixMilli(), 10)
+	return ts + "." + s.mac(ts)
+}
+
+// Verify returns the time a stamp was issued, if it is genuine and no older
+// than StampTTL at now.
+func (s *Stamper) Verify(stamp string, now time.Time) (time.Time, bool) {
+	ts, mac, ok := strings.Cut(stamp, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(s.mac(ts))) {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t := time.UnixMilli(ms)
+	if age := now.Sub(t); age < 0 || age > StampTTL {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (s *Stamper) mac(ts string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(ts))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
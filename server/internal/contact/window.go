@@ -0,0 +1,44 @@
+package contact
+
+import (
+	"sync"
+	"time"
+)
+
+// Window remembers keys for a fixed period; it backs the auto-reply limit
+// per recipient and duplicate submission detection. State is in memory and
+// resets on restart.
+type Window struct {
+	mu     sync.Mutex
+	period time.Duration
+	seen   map[string]time.Time
+}
+
+func NewWindow(period time.Duration) *Window {
+	return &Window{period: period, seen: map[string]time.Time{}}
+}
+
+// Allow records key and reports whether it was not already seen within
+// the period.
+func (w *Window) Allow(key string, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for k, t := range w.seen {
+		if now.Sub(t) >= w.period {
+			delete(w.seen, k)
+		}
+	}
+	if _, ok := w.seen[key]; ok {
+		return false
+	}
+	w.seen[key] = now
+	return true
+}
+
+// Forget drops key, e.g. when the submission it recorded was not accepted
+// and a retry must go through.
+func (w *Window) Forget(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.seen, key)
+}
@@ -0,0 +1,150 @@
+// Package embed signs and verifies gallery widget configurations so
+// third-party pages can embed a filtered subset of the portfolio without
+// being able to change the parameters.
+package embed
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+)
+
+const (
+	maxCount     = 60
+	defaultCount = 12
+)
+
+var layouts = []string{"grid", "masonry", "strip"}
+
+var (
+	ErrDisabled = errors.New("embeds disabled: EMBED_SECRET not set")
+	ErrBadToken = errors.New("invalid embed token")
+)
+
+// Config is the signed part of an embed.
+type Config struct {
+	Album     string   `json:"album,omitempty"` // must match the cached album when set
+	Tags      []string `json:"tags,omitempty"`  // tag IDs or names; any match keeps a photo
+	Count     int      `json:"count,omitempty"`
+	Layout    string   `json:"layout,omitempty"`
+	Ancestors []string `json:"ancestors,omitempty"` // allowed frame-ancestors origins; empty allows any
+}
+
+// Normalize fills defaults and validates c.
+func (c *Config) Normalize() error {
+	if c.Count == 0 {
+		c.Count = defaultCount
+	}
+	if c.Count < 1 || c.Count > maxCount {
+		return fmt.Errorf("count must be 1-%d", maxCount)
+	}
+	if c.Layout == "" {
+		c.Layout = layouts[0]
+	}
+	if !slices.Contains(layouts, c.Layout) {
+		return fmt.Errorf("layout must be one of %s", strings.Join(layouts, ", "))
+	}
+	for i, a := range c.Ancestors {
+		u, err := url.Parse(a)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("ancestor %q must be an origin like https://example.com", a)
+		}
+		c.Ancestors[i] = u.Scheme + "://" + u.Host
+	}
+	return nil
+}
+
+// Sign returns "<payload>.<mac>", both base64url without padding.
+func Sign(secret string, c Config) (string, error) {
+	if secret == "" {
+		return "", ErrDisabled
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac(secret, payload)), nil
+}
+
+// Verify checks the signature and decodes the configuration.
+func Verify(secret, token string) (Config, error) {
+	var c Config
+	if secret == "" {
+		return c, ErrDisabled
+	}
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return c, ErrBadToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(secret, payload)) {
+		return c, ErrBadToken
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return c, ErrBadToken
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, ErrBadToken
+	}
+	if err := c.Normalize(); err != nil {
+		return c, ErrBadToken
+	}
+	return c, nil
+}
+
+func mac(secret, payload string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte("embed:v1:" + payload))
+	return h.Sum(nil)
+}
+
+// FrameAncestors is the CSP directive for c.
+func (c Config) FrameAncestors() string {
+	if len(c.Ancestors) == 0 {
+		return "frame-ancestors *"
+	}
+	return "frame-ancestors 'self' " + strings.Join(c.Ancestors, " ")
+}
+
+// Select applies the album, tag and count filters to the public cache.
+func (c Config) Select(data cache.File) []cache.Item {
+	if c.Album != "" && c.Album != data.Album.ID {
+		return nil
+	}
+	want := map[string]bool{}
+	for _, t := range c.Tags {
+		want[strings.ToLower(t)] = true
+	}
+	out := make([]cache.Item, 0, c.Count)
+	for _, it := range data.Items {
+		if len(out) == c.Count {
+			break
+		}
+		if it.PreviewPath == "" || (len(want) > 0 && !hasTag(it, want)) {
+			continue
+		}
+		out = append(out, it)
+	}
+	return out
+}
+
+func hasTag(it cache.Item, want map[string]bool) bool {
+	for _, t := range it.Tags {
+		if want[strings.ToLower(t.ID)] ||
+			(t.Name != nil && want[strings.ToLower(*t.Name)]) ||
+			(t.Value != nil && want[strings.ToLower(*t.Value)]) {
+			return true
+		}
+	}
+	return false
+}
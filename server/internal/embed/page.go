@@ -0,0 +1,46 @@
+package embed
+
+import "html/template"
+
+// Page is the self-contained widget document served inside the iframe.
+var Page = template.Must(template.New("embed").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width,initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+  html,body{margin:0;background:transparent;font-family:system-ui,-apple-system,Segoe UI,Roboto,Helvetica,Arial,sans-serif}
+  #g{display:grid;gap:6px;padding:6px}
+  #g.grid{grid-template-columns:repeat(auto-fill,minmax(180px,1fr))}
+  #g.grid img{aspect-ratio:1;object-fit:cover}
+  #g.masonry{display:block;columns:3 180px;column-gap:6px}
+  #g.masonry a{margin-bottom:6px}
+  #g.strip{grid-auto-flow:column;grid-auto-columns:minmax(220px,1fr);overflow-x:auto}
+  #g.strip img{height:220px;object-fit:cover}
+  a{display:block;break-inside:avoid}
+  img{display:block;width:100%;height:auto;border-radius:6px;background:#ddd}
+  footer{font-size:12px;padding:0 8px 6px;text-align:right}
+  footer a{display:inline;color:inherit;opacity:.7}
+</style>
+</head>
+<body>
+<div id="g" class="{{.Layout}}"></div>
+<footer><a href="{{.Home}}" target="_blank" rel="noopener">{{.Title}}</a></footer>
+<script>
+fetch({{.ItemsURL}}).then(function (r) { return r.json(); }).then(function (d) {
+  var g = document.getElementById('g');
+  d.items.forEach(function (it) {
+    var a = document.createElement('a');
+    a.href = it.link; a.target = '_blank'; a.rel = 'noopener';
+    var img = document.createElement('img');
+    img.src = d.layout === 'grid' && it.thumb ? it.thumb : it.src;
+    img.alt = it.title; img.loading = 'lazy';
+    if (it.width && it.height) { img.width = it.width; img.height = it.height; }
+    a.appendChild(img); g.appendChild(a);
+  });
+});
+</script>
+</body>
+</html>
+`))
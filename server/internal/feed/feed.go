@@ -0,0 +1,370 @@
+// Package feed renders RSS 2.0, Atom and JSON Feed documents for newly
+// published photos. Documents are rebuilt after every cache publish and
+// written to DATA_DIR/feed/.
+package feed
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/fsutil"
+	"github.com/ShinysArc/photography-portfolio/server/internal/site"
+)
+
+const limit = 50
+
+// Document names, served under /api/feed/.
+const (
+	RSS  = "rss.xml"
+	Atom = "atom.xml"
+	JSON = "feed.json"
+)
+
+var ContentTypes = map[string]string{
+	RSS:  "application/rss+xml; charset=utf-8",
+	Atom: "application/atom+xml; charset=utf-8",
+	JSON: "application/feed+json; charset=utf-8",
+}
+
+func Dir(cfg config.Config) string {
+	return filepath.Join(cfg.DataDir, "feed")
+}
+
+type entry struct {
+	item      cache.Item
+	url       string
+	title     string
+	published time.Time
+	image     string
+	imageType string
+	imageSize int64
+	html      string
+	text      string
+	tags      []string
+}
+
+// Rebuild regenerates all feed documents. Files are only rewritten when
+// their content changes, so Last-Modified tracks real updates.
+func Rebuild(cfg config.Config, data cache.File) {
+	entries := collect(cfg, data)
+	var updated time.Time
+	if len(entries) > 0 {
+		updated = entries[0].published
+	}
+
+	if err := os.MkdirAll(Dir(cfg), 0o755); err != nil {
+		log.Printf("feed: %v", err)
+		return
+	}
+	for name, render := range map[string]func(config.Config, cache.File, []entry, time.Time) ([]byte, error){
+		RSS:  renderRSS,
+		Atom: renderAtom,
+		JSON: renderJSON,
+	} {
+		b, err := render(cfg, data, entries, updated)
+		if err != nil {
+			log.Printf("feed: render %s: %v", name, err)
+			continue
+		}
+		if err := fsutil.WriteIfChanged(filepath.Join(Dir(cfg), name), b, 0o644); err != nil {
+			log.Printf("feed: write %s: %v", name, err)
+		}
+	}
+}
+
+func collect(cfg config.Config, data cache.File) []entry {
+	out := make([]entry, 0, len(data.Items))
+	for _, it := range data.Items {
+		if it.PreviewPath == "" {
+			continue
+		}
+		var pub time.Time
+		if it.Published != nil {
+			pub, _ = time.Parse(time.RFC3339, *it.Published)
+		}
+		if pub.IsZero() {
+			pub, _ = it.TakenAt()
+		}
+
+		e := entry{
+			item:      it,
+			url:       site.PhotoURL(cfg, it.ID),
+			title:     site.Title(it),
+			published: pub.UTC(),
+			image:     site.ImageURL(cfg, it),
+			imageType: site.ImageType(it),
+			tags:      site.TagNames(it),
+		}
+		if st, err := os.Stat(filepath.Join(cfg.DataDir, it.PreviewPath)); err == nil {
+			e.imageSize = st.Size()
+		}
+
+		var text []string
+		h := `<p><img src="` + html.EscapeString(e.image) + `" alt="` + html.EscapeString(e.title) + `"/></p>`
+		if it.Description != nil {
+			text = append(text, *it.Description)
+			h += "<p>" + strings.ReplaceAll(html.EscapeString(*it.Description), "\n", "<br/>") + "</p>"
+		}
+		if line := site.ExifLine(it); line != "" {
+			text = append(text, line)
+			h += "<p><small>" + html.EscapeString(line) + "</small></p>"
+		}
+		e.html, e.text = h, strings.Join(text, "\n\n")
+		out = append(out, e)
+	}
+
+	slices.SortStableFunc(out, func(a, b entry) int {
+		return cmp.Or(b.published.Compare(a.published), strings.Compare(a.item.ID, b.item.ID))
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func feedTitle(cfg config.Config, data cache.File) string {
+	if cfg.SiteTitle != "" {
+		return cfg.SiteTitle
+	}
+	return data.Album.Name
+}
+
+func selfURL(cfg config.Config, name string) string {
+	return cfg.PublicURL + "/api/feed/" + name
+}
+
+type rssDoc struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	AtomNS  string   `xml:"xmlns:atom,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+		Self          rssLink   `xml:"atom:link"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Description string   `xml:"description"`
+	Enclosure   *rssEnc  `xml:"enclosure,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnc struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+func renderRSS(cfg config.Config, data cache.File, entries []entry, updated time.Time) ([]byte, error) {
+	var doc rssDoc
+	doc.Version = "2.0"
+	doc.AtomNS = "http://www.w3.org/2005/Atom"
+	doc.Channel.Title = feedTitle(cfg, data)
+	doc.Channel.Link = site.Home(cfg)
+	doc.Channel.Description = "New photos from " + feedTitle(cfg, data)
+	if !updated.IsZero() {
+		doc.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+	doc.Channel.Self = rssLink{Href: selfURL(cfg, RSS), Rel: "self", Type: "application/rss+xml"}
+	for _, e := range entries {
+		it := rssItem{
+			Title:       e.title,
+			Link:        e.url,
+			GUID:        rssGUID{Value: e.item.ID},
+			Description: e.html,
+			Categories:  e.tags,
+		}
+		if !e.published.IsZero() {
+			it.PubDate = e.published.Format(time.RFC1123Z)
+		}
+		if e.image != "" {
+			it.Enclosure = &rssEnc{URL: e.image, Length: e.imageSize, Type: e.imageType}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, it)
+	}
+	return marshalXML(doc)
+}
+
+type atomDoc struct {
+	XMLName xml.Name    `xml:"feed"`
+	NS      string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    atomText       `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func renderAtom(cfg config.Config, data cache.File, entries []entry, updated time.Time) ([]byte, error) {
+	doc := atomDoc{
+		NS:      "http://www.w3.org/2005/Atom",
+		ID:      site.Home(cfg),
+		Title:   feedTitle(cfg, data),
+		Updated: atomTime(updated),
+		Links: []atomLink{
+			{Href: selfURL(cfg, Atom), Rel: "self", Type: "application/atom+xml"},
+			{Href: site.Home(cfg), Rel: "alternate", Type: "text/html"},
+		},
+	}
+	if cfg.AuthorName != "" {
+		doc.Author = &atomAuthor{Name: cfg.AuthorName}
+	}
+	for _, e := range entries {
+		ae := atomEntry{
+			ID:        e.url,
+			Title:     e.title,
+			Updated:   atomTime(e.published),
+			Published: atomTime(e.published),
+			Links:     []atomLink{{Href: e.url, Rel: "alternate", Type: "text/html"}},
+			Summary:   e.text,
+			Content:   atomText{Type: "html", Value: e.html},
+		}
+		if e.image != "" {
+			ae.Links = append(ae.Links, atomLink{Href: e.image, Rel: "enclosure", Type: e.imageType, Length: e.imageSize})
+		}
+		for _, t := range e.tags {
+			ae.Categories = append(ae.Categories, atomCategory{Term: t})
+		}
+		doc.Entries = append(doc.Entries, ae)
+	}
+	return marshalXML(doc)
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url"`
+	FeedURL     string       `json:"feed_url"`
+	Authors     []jsonAuthor `json:"authors,omitempty"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	ContentHTML   string       `json:"content_html"`
+	ContentText   string       `json:"content_text,omitempty"`
+	Image         string       `json:"image,omitempty"`
+	DatePublished string       `json:"date_published,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+	Attachments   []jsonAttach `json:"attachments,omitempty"`
+}
+
+type jsonAttach struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size_in_bytes,omitempty"`
+}
+
+func renderJSON(cfg config.Config, data cache.File, entries []entry, _ time.Time) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feedTitle(cfg, data),
+		HomePageURL: site.Home(cfg),
+		FeedURL:     selfURL(cfg, JSON),
+		Items:       make([]jsonItem, 0, len(entries)),
+	}
+	if cfg.AuthorName != "" {
+		doc.Authors = []jsonAuthor{{Name: cfg.AuthorName}}
+	}
+	for _, e := range entries {
+		ji := jsonItem{
+			ID:          e.item.ID,
+			URL:         e.url,
+			Title:       e.title,
+			ContentHTML: e.html,
+			ContentText: e.text,
+			Image:       e.image,
+			Tags:        e.tags,
+		}
+		if !e.published.IsZero() {
+			ji.DatePublished = e.published.Format(time.RFC3339)
+		}
+		if e.image != "" {
+			ji.Attachments = []jsonAttach{{URL: e.image, MimeType: e.imageType, Size: e.imageSize}}
+		}
+		doc.Items = append(doc.Items, ji)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func marshalXML(v any) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
@@ -0,0 +1,51 @@
+package fsutil
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomic replaces p with b. The data goes to a uniquely named temp file
+// in the same directory first and is fsynced before the rename, so readers
+// never see a partial file, a crash never leaves an empty one, and
+// concurrent writers never share a temp file.
+func WriteAtomic(p string, b []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// WriteIfChanged is WriteAtomic that leaves p untouched when it already
+// holds b, keeping its mtime for conditional requests.
+func WriteIfChanged(p string, b []byte, perm os.FileMode) error {
+	if old, err := os.ReadFile(p); err == nil && bytes.Equal(old, b) {
+		return nil
+	}
+	return WriteAtomic(p, b, perm)
+}
@@ -0,0 +1,187 @@
+// Package gear derives the camera and lens catalog from cached EXIF usage.
+package gear
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+const maxSamples = 6
+
+// Meta is the hand-written part of a gear entry, from CONTENT_DIR/gear.json:
+//
+//	{ "items": { "Canon EOS R6 Mark II": { "description": "...", "photo": "/gear/r6.jpg" } } }
+type Meta struct {
+	Name        string `json:"name,omitempty"` // display override
+	Description string `json:"description,omitempty"`
+	Note        string `json:"note,omitempty"`
+	Photo       string `json:"photo,omitempty"` // URL or /public path
+	Hidden      bool   `json:"hidden,omitempty"`
+}
+
+type metaFile struct {
+	Items map[string]Meta `json:"items"`
+}
+
+type Settings struct {
+	FocalLength string `json:"focalLength,omitempty"`
+	Aperture    string `json:"aperture,omitempty"`
+	Exposure    string `json:"exposure,omitempty"`
+	ISO         string `json:"iso,omitempty"`
+}
+
+type Item struct {
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"` // "camera" or "lens"
+	Shots       int        `json:"shots"`
+	FirstUsed   *time.Time `json:"firstUsed,omitempty"`
+	LastUsed    *time.Time `json:"lastUsed,omitempty"`
+	TopSettings Settings   `json:"topSettings"`
+	Samples     []string   `json:"samples"` // asset IDs
+	Description string     `json:"description,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	Photo       string     `json:"photo,omitempty"`
+}
+
+type Catalog struct {
+	Cameras []Item `json:"cameras"`
+	Lenses  []Item `json:"lenses"`
+}
+
+func metaPath(cfg config.Config) string {
+	return filepath.Join(cfg.ContentDir, "gear.json")
+}
+
+// LoadMeta reads the optional gear metadata file; a missing file is not an error.
+func LoadMeta(cfg config.Config) (map[string]Meta, error) {
+	out := map[string]Meta{}
+	b, err := os.ReadFile(metaPath(cfg))
+	if errors.Is(err, fs.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f metaFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	for k, v := range f.Items {
+		out[strings.ToLower(k)] = v
+	}
+	return out, nil
+}
+
+type tally struct {
+	item     Item
+	settings [4]map[string]int
+	samples  []cache.Item
+}
+
+// Build aggregates the public cache into a catalog, merged with meta.
+func Build(data cache.File, meta map[string]Meta) Catalog {
+	cams := map[string]*tally{}
+	lenses := map[string]*tally{}
+
+	add := func(m map[string]*tally, kind, name string, it cache.Item) {
+		if name == "" {
+			return
+		}
+		t := m[name]
+		if t == nil {
+			t = &tally{item: Item{Name: name, Kind: kind}}
+			for i := range t.settings {
+				t.settings[i] = map[string]int{}
+			}
+			m[name] = t
+		}
+		t.item.Shots++
+		d := it.Display
+		for i, v := range []string{d.FocalLength, d.Aperture, d.Exposure, d.ISO} {
+			if v != "" {
+				t.settings[i][v]++
+			}
+		}
+		if at, ok := it.TakenAt(); ok {
+			if t.item.FirstUsed == nil || at.Before(*t.item.FirstUsed) {
+				t.item.FirstUsed = &at
+			}
+			if t.item.LastUsed == nil || at.After(*t.item.LastUsed) {
+				t.item.LastUsed = &at
+			}
+		}
+		t.samples = append(t.samples, it)
+	}
+
+	for _, it := range data.Items {
+		if it.Display == nil {
+			continue
+		}
+		add(cams, "camera", it.Display.Camera, it)
+		add(lenses, "lens", it.Display.Lens, it)
+	}
+
+	return Catalog{Cameras: finish(cams, meta), Lenses: finish(lenses, meta)}
+}
+
+func finish(m map[string]*tally, meta map[string]Meta) []Item {
+	out := make([]Item, 0, len(m))
+	for _, t := range m {
+		md := meta[strings.ToLower(t.item.Name)]
+		if md.Hidden {
+			continue
+		}
+		it := t.item
+		it.TopSettings = Settings{
+			FocalLength: mostUsed(t.settings[0]),
+			Aperture:    mostUsed(t.settings[1]),
+			Exposure:    mostUsed(t.settings[2]),
+			ISO:         mostUsed(t.settings[3]),
+		}
+		it.Samples = samples(t.samples)
+		if md.Name != "" {
+			it.Name = md.Name
+		}
+		it.Description, it.Note, it.Photo = md.Description, md.Note, md.Photo
+		out = append(out, it)
+	}
+	slices.SortFunc(out, func(a, b Item) int {
+		return cmp.Or(cmp.Compare(b.Shots, a.Shots), cmp.Compare(a.Name, b.Name))
+	})
+	return out
+}
+
+func mostUsed(counts map[string]int) string {
+	best, n := "", 0
+	for v, c := range counts {
+		if c > n || (c == n && v < best) {
+			best, n = v, c
+		}
+	}
+	return best
+}
+
+// samples prefers favorites, then higher ratings, keeping cache order otherwise.
+func samples(items []cache.Item) []string {
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b cache.Item) int { return cmp.Compare(b.Score(), a.Score()) })
+
+	ids := make([]string, 0, maxSamples)
+	for _, it := range sorted {
+		if len(ids) == maxSamples {
+			break
+		}
+		ids = append(ids, it.ID)
+	}
+	return ids
+}
@@ -0,0 +1,180 @@
+// Package geocode resolves coordinates to place names offline, from a
+// GeoNames-style dump loaded into a k-d tree.
+package geocode
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/*
+Dataset layout (same files and columns as download.geonames.org/export/dump):
+
+	cities*.txt           geonameid, name, asciiname, alternatenames, lat, lon,
+	                      feature class, feature code, country code, cc2, admin1, ...
+	countryInfo.txt       ISO, ISO3, ISO-Numeric, fips, Country, ...
+	admin1CodesASCII.txt  "CC.admin1", name, asciiname, geonameid
+
+The bundled data/ directory is a small hand-picked subset of major cities;
+point GEOCODE_DIR at full GeoNames dumps (e.g. cities1000.txt) for real use.
+*/
+
+//go:embed data/*.txt
+var bundled embed.FS
+
+const earthRadiusKm = 6371.0
+
+type Place struct {
+	City    string
+	Region  string
+	Country string
+	Lat     float64
+	Lon     float64
+}
+
+type Geocoder struct {
+	places []Place
+	root   *node
+}
+
+// New indexes places for nearest-neighbour lookups.
+func New(places []Place) *Geocoder {
+	pts := make([]point, len(places))
+	for i, p := range places {
+		pts[i] = point{xyz: toXYZ(p.Lat, p.Lon), idx: i}
+	}
+	return &Geocoder{places: places, root: build(pts, 0)}
+}
+
+// Load reads a dataset from dir, or the bundled one when dir is empty.
+func Load(dir string) (*Geocoder, error) {
+	var fsys fs.FS
+	if dir == "" {
+		sub, err := fs.Sub(bundled, "data")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	} else {
+		fsys = os.DirFS(dir)
+	}
+
+	countries, err := readTable(fsys, "countryInfo.txt", 0, 4)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	regions, err := readTable(fsys, "admin1CodesASCII.txt", 0, 1)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	files, err := fs.Glob(fsys, "cities*.txt")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("geocode: no cities*.txt in %q", dir)
+	}
+
+	var places []Place
+	for _, name := range files {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		places, err = readCities(f, places, countries, regions)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("geocode: %s: %w", filepath.Base(name), err)
+		}
+	}
+	return New(places), nil
+}
+
+func readCities(r io.Reader, places []Place, countries, regions map[string]string) ([]Place, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024) // alternatenames can be long
+	for sc.Scan() {
+		cols := strings.Split(sc.Text(), "\t")
+		if len(cols) < 11 {
+			continue
+		}
+		lat, err1 := strconv.ParseFloat(cols[4], 64)
+		lon, err2 := strconv.ParseFloat(cols[5], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		cc := cols[8]
+		country := countries[cc]
+		if country == "" {
+			country = cc
+		}
+		places = append(places, Place{
+			City:    cols[1],
+			Region:  regions[cc+"."+cols[10]],
+			Country: country,
+			Lat:     lat,
+			Lon:     lon,
+		})
+	}
+	return places, sc.Err()
+}
+
+// readTable maps column key to column val for every non-comment line.
+func readTable(fsys fs.FS, name string, key, val int) (map[string]string, error) {
+	out := map[string]string{}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return out, err
+	}
+	defer func() { _ = f.Close() }()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) > max(key, val) {
+			out[cols[key]] = cols[val]
+		}
+	}
+	return out, sc.Err()
+}
+
+// Lookup returns the place nearest to lat/lon within maxKm (0 = unbounded).
+func (g *Geocoder) Lookup(lat, lon, maxKm float64) (Place, bool) {
+	if g == nil || g.root == nil {
+		return Place{}, false
+	}
+	best, dist2 := g.root.nearest(toXYZ(lat, lon), -1, math.Inf(1))
+	if best < 0 {
+		return Place{}, false
+	}
+	if maxKm > 0 && chordToKm(math.Sqrt(dist2)) > maxKm {
+		return Place{}, false
+	}
+	return g.places[best], true
+}
+
+func (g *Geocoder) Len() int { return len(g.places) }
+
+func toXYZ(lat, lon float64) [3]float64 {
+	la, lo := lat*math.Pi/180, lon*math.Pi/180
+	return [3]float64{math.Cos(la) * math.Cos(lo), math.Cos(la) * math.Sin(lo), math.Sin(la)}
+}
+
+// chordToKm converts a unit-sphere chord length to great-circle distance.
+func chordToKm(c float64) float64 {
+	return 2 * math.Asin(math.Min(1, c/2)) * earthRadiusKm
+}
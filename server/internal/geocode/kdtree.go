@@ -0,0 +1,57 @@
+package geocode
+
+import "sort"
+
+// Points live on the unit sphere in 3D, so Euclidean nearest neighbour is
+// also great-circle nearest and there is no antimeridian special case.
+
+type point struct {
+	xyz [3]float64
+	idx int
+}
+
+type node struct {
+	p           point
+	axis        int
+	left, right *node
+}
+
+func build(pts []point, depth int) *node {
+	if len(pts) == 0 {
+		return nil
+	}
+	axis := depth % 3
+	sort.Slice(pts, func(i, j int) bool { return pts[i].xyz[axis] < pts[j].xyz[axis] })
+	mid := len(pts) / 2
+	return &node{
+		p:     pts[mid],
+		axis:  axis,
+		left:  build(pts[:mid], depth+1),
+		right: build(pts[mid+1:], depth+1),
+	}
+}
+
+func dist2(a, b [3]float64) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dx*dx + dy*dy + dz*dz
+}
+
+func (n *node) nearest(q [3]float64, best int, bestD float64) (int, float64) {
+	if n == nil {
+		return best, bestD
+	}
+	if d := dist2(q, n.p.xyz); d < bestD {
+		best, bestD = n.p.idx, d
+	}
+
+	diff := q[n.axis] - n.p.xyz[n.axis]
+	near, far := n.left, n.right
+	if diff > 0 {
+		near, far = n.right, n.left
+	}
+	best, bestD = near.nearest(q, best, bestD)
+	if diff*diff < bestD {
+		best, bestD = far.nearest(q, best, bestD)
+	}
+	return best, bestD
+}
@@ -0,0 +1,48 @@
+package geocode
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNearestMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randPlace := func() (float64, float64) {
+		return math.Asin(2*rng.Float64()-1) * 180 / math.Pi, rng.Float64()*360 - 180
+	}
+
+	places := make([]Place, 2000)
+	for i := range places {
+		places[i].Lat, places[i].Lon = randPlace()
+	}
+	g := New(places)
+
+	for i := 0; i < 500; i++ {
+		lat, lon := randPlace()
+		q := toXYZ(lat, lon)
+
+		want := math.Inf(1)
+		for _, p := range places {
+			want = math.Min(want, dist2(q, toXYZ(p.Lat, p.Lon)))
+		}
+		got, ok := g.Lookup(lat, lon, 0)
+		if !ok {
+			t.Fatalf("no result for %v,%v", lat, lon)
+		}
+		if d := dist2(q, toXYZ(got.Lat, got.Lon)); d != want {
+			t.Fatalf("%v,%v: k-d tree distance %v, brute force %v", lat, lon, d, want)
+		}
+	}
+}
+
+func TestLookupMaxKm(t *testing.T) {
+	g := New([]Place{{City: "Paris", Lat: 48.8566, Lon: 2.3522}})
+	// Fontainebleau is about 55 km from Paris.
+	if _, ok := g.Lookup(48.4047, 2.7016, 50); ok {
+		t.Error("matched a city beyond maxKm")
+	}
+	if p, ok := g.Lookup(48.4047, 2.7016, 60); !ok || p.City != "Paris" {
+		t.Errorf("Lookup = %+v, %v", p, ok)
+	}
+}
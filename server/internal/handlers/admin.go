@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+func isAdmin(cfg config.Config, r *http.Request) bool {
+	tok := r.Header.Get("x-admin-token")
+	return tok != "" && subtle.ConstantTimeCompare([]byte(tok), []byte(cfg.AdminToken)) == 1
+}
+
+func adminOnly(cfg config.Config, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(cfg, r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func getOrderHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o, err := cache.GetOrdering(cfg, cfg.ImmichAlbumID)
+		if err != nil {
+			http.Error(w, "read ordering: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, o)
+	}
+}
+
+// putOrderHandler replaces the album ordering and reapplies it to the cache.
+func putOrderHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var o cache.Ordering
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&o); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		if err := o.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := cache.SetOrdering(cfg, cfg.ImmichAlbumID, o); err != nil {
+			http.Error(w, "save ordering: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data, err := cache.Reorder(r.Context(), cfg)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Nothing cached yet; the next refresh applies it.
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true, "applied": false})
+			return
+		}
+		if err != nil {
+			http.Error(w, "reorder failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "applied": true, "count": len(data.Items)})
+	}
+}
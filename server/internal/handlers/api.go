@@ -3,30 +3,72 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/captcha"
 	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/contact"
 	"github.com/ShinysArc/photography-portfolio/server/internal/mail"
+	"github.com/ShinysArc/photography-portfolio/server/internal/stories"
 )
 
-type contactPayload struct {
-	Name      string  `json:"name"`
-	Email     string  `json:"email"`
-	Subject   string  `json:"subject"`
-	Message   string  `json:"message"`
-	HP        *string `json:"hp"`
-	StartedAt *int64  `json:"startedAt"`
-}
-
-func RegisterAll(mux *http.ServeMux, cfg config.Config, mailer *mail.Mailer) {
+func RegisterAll(mux *http.ServeMux, cfg config.Config, mailer *mail.Mailer, verifier captcha.Verifier) {
 	mux.HandleFunc("GET /healthz", healthz)
+	mux.HandleFunc("GET /api/map", mapHandler(cfg))
+	mux.HandleFunc("GET /api/gear", gearHandler(cfg))
+	mux.HandleFunc("GET /api/stats", statsHandler(cfg))
+	mux.HandleFunc("GET /api/timeline", timelineHandler(cfg))
+	mux.HandleFunc("GET /api/timeline/{period}", timelinePeriodHandler(cfg))
+	mux.HandleFunc("GET /api/stories", storiesHandler(cfg))
+	mux.HandleFunc("GET /api/stories/{slug}", storyHandler(cfg))
+	mux.HandleFunc("GET /api/feed/{name}", feedHandler(cfg))
+	mux.HandleFunc("GET /api/sitemap.xml", sitemapHandler(cfg))
+	mux.HandleFunc("GET /api/og/album", ogHandler(cfg))
+	mux.HandleFunc("GET /api/og/photo/{id}", ogHandler(cfg))
+	mux.HandleFunc("GET /api/oembed", oembedHandler(cfg))
+	mux.HandleFunc("GET /api/embed/{token}", embedPageHandler(cfg))
+	mux.HandleFunc("GET /api/embed/{token}/items", embedItemsHandler(cfg))
+
+	// Admin
+	mux.HandleFunc("GET /api/admin/order", adminOnly(cfg, getOrderHandler(cfg)))
+	mux.HandleFunc("PUT /api/admin/order", adminOnly(cfg, putOrderHandler(cfg)))
+	mux.HandleFunc("POST /api/admin/embeds", adminOnly(cfg, createEmbedHandler(cfg)))
+	mux.HandleFunc("GET /api/admin/mail", adminOnly(cfg, mailQueueHandler(cfg)))
+	mux.HandleFunc("POST /api/admin/mail/{id}/resend", adminOnly(cfg, mailResendHandler(cfg)))
+	mux.HandleFunc("DELETE /api/admin/mail/{id}", adminOnly(cfg, mailDiscardHandler(cfg)))
+	mux.HandleFunc("GET /api/admin/inbox", adminOnly(cfg, inboxListHandler(cfg)))
+	mux.HandleFunc("GET /api/admin/inbox/{id}", adminOnly(cfg, inboxGetHandler(cfg)))
+	mux.HandleFunc("GET /api/admin/inbox/{id}/attachments/{n}", adminOnly(cfg, inboxAttachmentHandler(cfg)))
+	mux.HandleFunc("PATCH /api/admin/inbox/{id}", adminOnly(cfg, inboxMarkHandler(cfg)))
+	mux.HandleFunc("DELETE /api/admin/inbox/{id}", adminOnly(cfg, inboxDeleteHandler(cfg)))
+	mux.HandleFunc("POST /api/admin/inbox/purge", adminOnly(cfg, inboxPurgeHandler(cfg)))
 
 	// Cache read
 	mux.HandleFunc("GET /api/cache", func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseCacheQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !q.IsZero() {
+			data, err := cache.Load(cfg)
+			if err != nil {
+				http.Error(w, "cache unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			if err := json.NewEncoder(w).Encode(data.Apply(q)); err != nil {
+				http.Error(w, "encode error", http.StatusInternalServerError)
+			}
+			return
+		}
+
 		b, err := cache.Read(cfg)
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
@@ -46,7 +88,7 @@ func RegisterAll(mux *http.ServeMux, cfg config.Config, mailer *mail.Mailer) {
 
 	// Refresh
 	mux.HandleFunc("POST /api/refresh", func(w http.ResponseWriter, r *http.Request) {
-		if r.Header.Get("x-admin-token") != cfg.AdminToken {
+		if !isAdmin(cfg, r) {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -57,79 +99,54 @@ func RegisterAll(mux *http.ServeMux, cfg config.Config, mailer *mail.Mailer) {
 			http.Error(w, "refresh failed: "+err.Error(), 500)
 			return
 		}
+		warnings := append(cache.Check(cfg), stories.Check(cfg, data)...)
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(map[string]any{
-			"ok":    true,
-			"count": len(data.Items),
-			"album": data.Album,
+			"ok":       true,
+			"count":    len(data.Items),
+			"album":    data.Album,
+			"warnings": warnings,
 		}); err != nil {
 			http.Error(w, "encode error", http.StatusInternalServerError)
 			return
 		}
 		// after responding:
 		log.Printf("refresh ok: album=%s count=%d", data.Album.ID, len(data.Items))
-	})
-
-	// Contact (SMTP via go-mail)
-	mux.HandleFunc("POST /api/contact", func(w http.ResponseWriter, r *http.Request) {
-		if mailer == nil {
-			http.Error(w, "mail not configured", 500)
-			return
-		}
-		var p contactPayload
-		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-			http.Error(w, "bad json", 400)
-			return
-		}
-		if strings.TrimSpace(p.Name) == "" || strings.TrimSpace(p.Email) == "" || strings.TrimSpace(p.Message) == "" {
-			http.Error(w, "missing fields", 400)
-			return
-		}
-		if p.HP != nil && strings.TrimSpace(*p.HP) != "" {
-			w.Header().Set("Content-Type", "application/json")
-			if _, err := w.Write([]byte(`{"ok":true}`)); err != nil {
-				http.Error(w, "write error", http.StatusInternalServerError)
-				return
-			}
-		}
-		if p.StartedAt != nil && time.Since(time.UnixMilli(*p.StartedAt)) < 3*time.Second {
-			w.Header().Set("Content-Type", "application/json")
-			if _, err := w.Write([]byte(`{"ok":true}`)); err != nil {
-				http.Error(w, "write error", http.StatusInternalServerError)
-				return
-			}
-		}
-
-		subj := "[Portfolio] " + p.Name
-		if s := strings.TrimSpace(p.Subject); s != "" {
-			subj += " — " + s
-		}
-		text := "From: " + p.Name + " <" + p.Email + ">\n\n" + p.Message
-		html := `<div style="font-family:system-ui,-apple-system,Segoe UI,Roboto,Helvetica,Arial,sans-serif">` +
-			`<p><strong>From:</strong> ` + esc(p.Name) + ` &lt;` + esc(p.Email) + `&gt;</p>` +
-			func() string {
-				if strings.TrimSpace(p.Subject) == "" {
-					return ""
-				}
-				return `<p><strong>Subject:</strong> ` + esc(p.Subject) + `</p>`
-			}() +
-			`<hr/><pre style="white-space:pre-wrap;font:inherit">` + esc(p.Message) + `</pre></div>`
-
-		ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
-		defer cancel()
-		if err := mailer.Send(ctx, p.Email, subj, text, html); err != nil {
-			http.Error(w, "send failed: "+err.Error(), 500)
-			return
-		}
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := w.Write([]byte(`{"ok":true}`)); err != nil {
-			http.Error(w, "write error", http.StatusInternalServerError)
-			return
+		for _, msg := range warnings {
+			log.Printf("refresh warning: %s", msg)
 		}
 	})
+
+	// Contact
+	stamps := contact.NewStamper()
+	mux.HandleFunc("GET /api/captcha", captchaHandler(cfg, verifier))
+	mux.HandleFunc("GET /api/contact/stamp", contactStampHandler(stamps))
+	mux.HandleFunc("POST /api/contact", contactHandler(cfg, mailer, verifier, stamps))
 }
 
-func esc(s string) string {
-	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;")
-	return r.Replace(s)
+// parseCacheQuery reads ?minRating=N&favorites=1&sort=rating.
+func parseCacheQuery(r *http.Request) (cache.Query, error) {
+	var q cache.Query
+	v := r.URL.Query()
+	if s := v.Get("minRating"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > 5 {
+			return q, errors.New("minRating must be 0-5")
+		}
+		q.MinRating = n
+	}
+	switch v.Get("favorites") {
+	case "", "0", "false":
+	case "1", "true":
+		q.Favorites = true
+	default:
+		return q, errors.New("favorites must be a boolean")
+	}
+	switch s := v.Get("sort"); s {
+	case "", "rating":
+		q.Sort = s
+	default:
+		return q, errors.New("unknown sort: " + s)
+	}
+	return q, nil
 }
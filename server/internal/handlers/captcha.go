@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/captcha"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+// captchaHandler tells the contact form which challenge to render and, for
+// the proof-of-work provider, issues a fresh puzzle.
+func captchaHandler(cfg config.Config, v captcha.Verifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if v == nil {
+			writeJSON(w, http.StatusOK, map[string]any{"provider": "off"})
+			return
+		}
+		resp := map[string]any{"provider": v.Provider()}
+		if cfg.CaptchaSiteKey != "" {
+			resp["siteKey"] = cfg.CaptchaSiteKey
+		}
+		if pow, ok := v.(*captcha.PoW); ok {
+			c, err := pow.Issue(time.Now())
+			if err != nil {
+				http.Error(w, "challenge failed", http.StatusInternalServerError)
+				return
+			}
+			resp["pow"] = c
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// captchaStatus maps verification errors to responses: a missing token is
+// 400, a rejected one 403, and an unreachable provider 502.
+func captchaStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, captcha.ErrMissing):
+		return http.StatusBadRequest, "captcha required"
+	case errors.Is(err, captcha.ErrUnavailable):
+		return http.StatusBadGateway, "captcha verification unavailable"
+	default:
+		return http.StatusForbidden, "captcha failed"
+	}
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/captcha"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/contact"
+	"github.com/ShinysArc/photography-portfolio/server/internal/inbox"
+	"github.com/ShinysArc/photography-portfolio/server/internal/mail"
+	"github.com/ShinysArc/photography-portfolio/server/internal/middleware"
+	"github.com/ShinysArc/photography-portfolio/server/internal/site"
+)
+
+type contactPayload struct {
+	Name      string  `json:"name"`
+	Email     string  `json:"email"`
+	Subject   string  `json:"subject"`
+	Message   string  `json:"message"`
+	HP        *string `json:"hp"`
+	StartedAt *int64  `json:"startedAt"`
+	Stamp     string  `json:"stamp"`   // from GET /api/contact/stamp, preferred over startedAt
+	Captcha   string  `json:"captcha"` // provider token, or "challenge:nonce" for pow
+	AssetID   string  `json:"assetId"` // photo the message is about
+	Inquiry   string  `json:"inquiry"` // "print", "license" or "other"
+}
+
+// contactMail is the data passed to the contact mail templates.
+type contactMail struct {
+	Name      string
+	Email     string
+	Subject   string
+	Message   string
+	Received  time.Time
+	SiteTitle string
+	SiteURL   string
+	Inquiry   string
+	Photo     *contactPhoto
+	Files     []inbox.Attachment
+}
+
+// confirmMail is what the visitor's confirmation may show. It leaves out
+// everything the visitor typed: the address is unverified, so echoing text
+// would let anyone send their own content from the site's domain.
+type confirmMail struct {
+	SiteTitle string
+	SiteURL   string
+	Photo     *contactPhoto
+}
+
+// contactPhoto describes the photo an inquiry is about.
+type contactPhoto struct {
+	ID       string
+	Title    string
+	FileName string
+	Exif     string
+	Thumb    string
+	Link     string
+}
+
+// contactStampHandler issues the signed time the form was served.
+func contactStampHandler(stamps *contact.Stamper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, map[string]any{"stamp": stamps.Issue(time.Now())})
+	}
+}
+
+// duplicateWindow is how long an identical resubmission (double click,
+// browser retry) is acknowledged without being stored or mailed again.
+const duplicateWindow = 10 * time.Minute
+
+// contactReadTimeout is how long a contact request body, attachments
+// included, may take to arrive.
+const contactReadTimeout = 60 * time.Second
+
+// contactHandler runs the spam checks, stores the message in the inbox and
+// queues the owner notification and, when enabled, an auto-reply to the visitor (SMTP via
+// go-mail, delivered by mail.RunQueue).
+func contactHandler(cfg config.Config, mailer *mail.Mailer, verifier captcha.Verifier, stamps *contact.Stamper) http.HandlerFunc {
+	recent := contact.NewWindow(duplicateWindow)
+	replied := contact.NewWindow(cfg.AutoReplyWindow)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if mailer == nil {
+			http.Error(w, "mail not configured", 500)
+			return
+		}
+		// The server ReadTimeout is short; attachments get more time.
+		_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(contactReadTimeout))
+		p, uploads, cleanup, err := readContact(cfg, w, r)
+		defer cleanup()
+		var rerr *requestError
+		if errors.As(err, &rerr) {
+			http.Error(w, rerr.msg, rerr.code)
+			return
+		}
+		if err != nil {
+			log.Printf("contact: read upload: %v", err)
+			http.Error(w, "could not read request", 500)
+			return
+		}
+		if strings.TrimSpace(p.Name) == "" || strings.TrimSpace(p.Email) == "" || strings.TrimSpace(p.Message) == "" {
+			http.Error(w, "missing fields", 400)
+			return
+		}
+		photo, err := inquiryPhoto(cfg, &p)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		if verifier != nil {
+			if err := verifier.Verify(r.Context(), p.Captcha, middleware.ClientIP(r)); err != nil {
+				code, msg := captchaStatus(err)
+				log.Printf("contact: %s: %v", msg, err)
+				http.Error(w, msg, code)
+				return
+			}
+		}
+		sub := contact.Submission{
+			Name:    p.Name,
+			Email:   p.Email,
+			Subject: p.Subject,
+			Message: p.Message,
+			IP:      middleware.ClientIP(r),
+		}
+		if p.HP != nil {
+			sub.Honeypot = *p.HP
+		}
+		if t, ok := stamps.Verify(p.Stamp, time.Now()); ok {
+			sub.StartedAt = &t
+		}
+		if p.StartedAt != nil {
+			t := time.UnixMilli(*p.StartedAt)
+			sub.ClientAt = &t
+		}
+		verdict := contact.Check(cfg, sub, time.Now())
+		if verdict.Drop {
+			// Bots get the same answer as people, so they learn nothing.
+			log.Printf("contact: dropped from %s: %s", sub.IP, verdict.Reasons[0])
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+			return
+		}
+
+		sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(p.Email)) + "\x00" + p.Subject + "\x00" + p.Message))
+		dup := string(sum[:])
+		if !recent.Allow(dup, time.Now()) {
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+			return
+		}
+
+		rec := inbox.Message{
+			Name:    p.Name,
+			Email:   p.Email,
+			Subject: p.Subject,
+			Body:    p.Message,
+			IP:      sub.IP,
+			Score:   verdict.Score,
+			Reasons: verdict.Reasons,
+			AssetID: p.AssetID,
+			Inquiry: p.Inquiry,
+		}
+		if verdict.Spam(cfg) {
+			// Quarantined: kept for review in the inbox, never mailed.
+			rec.Status = inbox.Spam
+			if _, err := inbox.Add(cfg, rec, uploads...); err != nil {
+				log.Printf("contact: inbox store failed: %v", err)
+			}
+			log.Printf("contact: quarantined from %s, score %d: %s", sub.IP, verdict.Score, strings.Join(verdict.Reasons, ", "))
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+			return
+		}
+		stored, err := inbox.Add(cfg, rec, uploads...)
+		if err != nil {
+			// Only mail what the admin inbox holds; the visitor can retry.
+			log.Printf("contact: inbox store failed: %v", err)
+			recent.Forget(dup)
+			http.Error(w, "could not accept message", 500)
+			return
+		}
+		atts := make([]mail.Attachment, 0, len(stored.Attachments))
+		for _, a := range stored.Attachments {
+			atts = append(atts, mail.Attachment{Name: a.Name, Type: a.Type, Path: inbox.AttachmentPath(cfg, stored.ID, a)})
+		}
+
+		data := contactMail{
+			Name:      strings.TrimSpace(p.Name),
+			Email:     strings.TrimSpace(p.Email),
+			Subject:   strings.TrimSpace(p.Subject),
+			Message:   p.Message,
+			Received:  stored.Received,
+			SiteTitle: cfg.SiteTitle,
+			SiteURL:   site.Home(cfg),
+			Inquiry:   p.Inquiry,
+			Photo:     photo,
+			Files:     stored.Attachments,
+		}
+		msg, err := mail.Render(cfg, mail.Notify, r.Header.Get("Accept-Language"), data)
+		if err != nil {
+			log.Printf("contact: render failed: %v", err)
+			recent.Forget(dup)
+			http.Error(w, "could not accept message", 500)
+			return
+		}
+		if _, err := mail.Enqueue(cfg, mail.Message{ReplyTo: data.Email, Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML, Attachments: atts}); err != nil {
+			log.Printf("contact: enqueue failed: %v", err)
+			recent.Forget(dup)
+			http.Error(w, "could not accept message", 500)
+			return
+		}
+		if cfg.AutoReply {
+			autoReply(cfg, r, replied, data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"ok":true}`)); err != nil {
+			http.Error(w, "write error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// autoReply queues a fixed confirmation to the visitor once per address per
+// cfg.AutoReplyWindow, and only to plausible addresses. The address is
+// unverified, so the reply carries no visitor text and no Reply-To.
+func autoReply(cfg config.Config, r *http.Request, replied *contact.Window, data contactMail) {
+	if err := contact.CheckAddress(data.Email); err != nil {
+		log.Printf("contact: no auto-reply to %q: %v", data.Email, err)
+		return
+	}
+	if !replied.Allow(strings.ToLower(data.Email), time.Now()) {
+		log.Printf("contact: auto-reply to %q already sent recently", data.Email)
+		return
+	}
+	reply := confirmMail{SiteTitle: data.SiteTitle, SiteURL: data.SiteURL, Photo: data.Photo}
+	msg, err := mail.Render(cfg, mail.Confirm, r.Header.Get("Accept-Language"), reply)
+	if err != nil {
+		log.Printf("contact: render auto-reply: %v", err)
+		return
+	}
+	if _, err := mail.Enqueue(cfg, mail.Message{To: data.Email, Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML}); err != nil {
+		log.Printf("contact: enqueue auto-reply: %v", err)
+	}
+}
+
+// inquiryPhoto validates the optional inquiry type and asset. Print and
+// licensing inquiries must name a photo, which must be public.
+func inquiryPhoto(cfg config.Config, p *contactPayload) (*contactPhoto, error) {
+	p.AssetID = strings.TrimSpace(p.AssetID)
+	switch p.Inquiry {
+	case "", "other":
+	case "print", "license":
+		if p.AssetID == "" {
+			return nil, errors.New(p.Inquiry + " inquiries need an assetId")
+		}
+	default:
+		return nil, errors.New("inquiry must be print, license or other")
+	}
+	if p.AssetID == "" {
+		return nil, nil
+	}
+	if p.Inquiry == "" {
+		p.Inquiry = "other"
+	}
+
+	data, err := cache.Load(cfg)
+	if err != nil {
+		return nil, errors.New("photo unavailable")
+	}
+	for _, it := range data.Items {
+		if it.ID != p.AssetID {
+			continue
+		}
+		ph := &contactPhoto{
+			ID:    it.ID,
+			Title: site.Title(it),
+			Exif:  site.ExifLine(it),
+			Thumb: site.ImageURL(cfg, it),
+			Link:  site.PhotoURL(cfg, it.ID),
+		}
+		if it.OriginalFileName != nil {
+			ph.FileName = *it.OriginalFileName
+		}
+		if it.ThumbnailPath != "" {
+			ph.Thumb = cfg.PublicURL + "/photos/" + it.ThumbnailPath
+		}
+		return ph, nil
+	}
+	return nil, errors.New("unknown assetId")
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/embed"
+	"github.com/ShinysArc/photography-portfolio/server/internal/site"
+)
+
+type embedItem struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Src       string `json:"src"`
+	Thumb     string `json:"thumb,omitempty"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	ThumbHash string `json:"thumbHash,omitempty"`
+	Link      string `json:"link"`
+}
+
+// createEmbedHandler mints a signed widget token from a JSON embed.Config.
+func createEmbedHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var c embed.Config
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&c); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		if err := c.Normalize(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tok, err := embed.Sign(cfg.EmbedSecret, c)
+		if errors.Is(err, embed.ErrDisabled) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, "sign failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page := cfg.PublicURL + "/api/embed/" + tok
+		writeJSON(w, http.StatusOK, map[string]any{
+			"token":  tok,
+			"config": c,
+			"url":    page,
+			"items":  page + "/items",
+			"iframe": `<iframe src="` + page + `" width="100%" height="480" style="border:0" loading="lazy"></iframe>`,
+		})
+	}
+}
+
+// embedPageHandler serves the widget document for /api/embed/{token}.
+func embedPageHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := verifyEmbed(cfg, w, r)
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", c.FrameAncestors())
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		err := embed.Page.Execute(w, map[string]string{
+			"Title":    cfg.SiteTitle,
+			"Home":     site.Home(cfg),
+			"Layout":   c.Layout,
+			"ItemsURL": r.URL.Path + "/items",
+		})
+		if err != nil {
+			log.Printf("embed: %v", err)
+		}
+	}
+}
+
+// embedItemsHandler returns the photos selected by the signed configuration.
+func embedItemsHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := verifyEmbed(cfg, w, r)
+		if !ok {
+			return
+		}
+		data, err := cache.Load(cfg)
+		if err != nil {
+			http.Error(w, "cache unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		items := []embedItem{}
+		for _, it := range c.Select(data) {
+			e := embedItem{
+				ID:        it.ID,
+				Title:     site.Title(it),
+				Src:       site.ImageURL(cfg, it),
+				ThumbHash: it.ThumbHash,
+				Link:      site.PhotoURL(cfg, it.ID),
+			}
+			if it.ThumbnailPath != "" {
+				e.Thumb = cfg.PublicURL + "/photos/" + it.ThumbnailPath
+			}
+			if w, h, ok := it.Size(); ok {
+				e.Width, e.Height = w, h
+			}
+			items = append(items, e)
+		}
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		writeJSON(w, http.StatusOK, map[string]any{"layout": c.Layout, "items": items})
+	}
+}
+
+func verifyEmbed(cfg config.Config, w http.ResponseWriter, r *http.Request) (embed.Config, bool) {
+	c, err := embed.Verify(cfg.EmbedSecret, r.PathValue("token"))
+	switch {
+	case errors.Is(err, embed.ErrDisabled):
+		http.Error(w, "embeds disabled", http.StatusNotFound)
+		return c, false
+	case err != nil:
+		http.Error(w, "invalid embed", http.StatusForbidden)
+		return c, false
+	}
+	return c, true
+}
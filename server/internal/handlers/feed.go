@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/feed"
+)
+
+// feedHandler serves rss.xml, atom.xml and feed.json.
+func feedHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		ct, ok := feed.ContentTypes[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		serveDocument(w, r, filepath.Join(feed.Dir(cfg), name), ct)
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/gear"
+)
+
+// gearHandler serves cameras and lenses aggregated from cached EXIF.
+func gearHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := cache.Load(cfg)
+		if err != nil {
+			http.Error(w, "cache unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		meta, err := gear.LoadMeta(cfg)
+		if err != nil {
+			log.Printf("gear meta: %v", err)
+			meta = nil
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, gear.Build(data, meta))
+	}
+}
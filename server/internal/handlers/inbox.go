@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/inbox"
+	"github.com/ShinysArc/photography-portfolio/server/internal/mail"
+)
+
+// inboxListHandler serves ?status=&q=&offset=&limit=, newest first.
+func inboxListHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v := r.URL.Query()
+		f := inbox.Filter{Status: inbox.Status(v.Get("status")), Query: v.Get("q"), Limit: 50}
+		if f.Status != "" && !f.Status.Valid() {
+			http.Error(w, "unknown status", http.StatusBadRequest)
+			return
+		}
+		for _, p := range []struct {
+			key string
+			dst *int
+		}{{"offset", &f.Offset}, {"limit", &f.Limit}} {
+			if s := v.Get(p.key); s != "" {
+				n, err := strconv.Atoi(s)
+				if err != nil || n < 0 {
+					http.Error(w, p.key+" must be a non-negative integer", http.StatusBadRequest)
+					return
+				}
+				*p.dst = n
+			}
+		}
+
+		page, err := inbox.List(cfg, f)
+		if err != nil {
+			http.Error(w, "read inbox: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, page)
+	}
+}
+
+func inboxGetHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, err := inbox.Get(cfg, r.PathValue("id"))
+		if errors.Is(err, inbox.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "read inbox: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, m)
+	}
+}
+
+// inboxMarkHandler sets the status from {"status":"read"}.
+func inboxMarkHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Status inbox.Status `json:"status"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		if !body.Status.Valid() {
+			http.Error(w, "unknown status", http.StatusBadRequest)
+			return
+		}
+		err := inbox.Mark(cfg, r.PathValue("id"), body.Status)
+		if errors.Is(err, inbox.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "update inbox: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "status": body.Status})
+	}
+}
+
+func inboxDeleteHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := inbox.Delete(cfg, r.PathValue("id"))
+		if errors.Is(err, inbox.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "delete: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// inboxPurgeHandler applies the retention rules now.
+func inboxPurgeHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, err := inbox.Purge(cfg, time.Now())
+		if err != nil {
+			http.Error(w, "purge: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		nm, err := mail.Purge(cfg, time.Now())
+		if err != nil {
+			http.Error(w, "purge mail queue: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "purged": n, "mailPurged": nm})
+	}
+}
+
+// inboxAttachmentHandler downloads attachment {n} (1-based) of a message.
+func inboxAttachmentHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, err := inbox.Get(cfg, r.PathValue("id"))
+		if errors.Is(err, inbox.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "read inbox: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := strconv.Atoi(r.PathValue("n"))
+		if err != nil || n < 1 || n > len(m.Attachments) {
+			http.NotFound(w, r)
+			return
+		}
+		a := m.Attachments[n-1]
+		f, err := os.Open(inbox.AttachmentPath(cfg, m.ID, a))
+		if err != nil {
+			http.Error(w, "attachment missing", http.StatusNotFound)
+			return
+		}
+		defer func() { _ = f.Close() }()
+		w.Header().Set("Content-Type", a.Type)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+		http.ServeContent(w, r, "", m.Received, f)
+	}
+}
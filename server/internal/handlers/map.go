@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+type geoFeature struct {
+	Type     string         `json:"type"`
+	Geometry geoPoint       `json:"geometry"`
+	Props    map[string]any `json:"properties"`
+}
+
+type geoPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // lon, lat
+}
+
+// mapHandler serves public photo locations as a GeoJSON FeatureCollection.
+func mapHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := cache.Load(cfg)
+		if err != nil {
+			http.Error(w, "cache unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		features := make([]geoFeature, 0)
+		for _, it := range data.Items {
+			loc := it.Location
+			if loc == nil || loc.Lat == nil || loc.Lon == nil {
+				continue
+			}
+			props := map[string]any{"id": it.ID}
+			if it.OriginalFileName != nil {
+				props["title"] = *it.OriginalFileName
+			}
+			if it.PreviewPath != "" {
+				props["previewPath"] = it.PreviewPath
+			}
+			if it.ThumbHash != "" {
+				props["thumbHash"] = it.ThumbHash
+			}
+			if it.Exif.DateTimeOrig != nil {
+				props["dateTimeOriginal"] = *it.Exif.DateTimeOrig
+			}
+			if loc.City != nil {
+				props["city"] = *loc.City
+			}
+			if loc.State != nil {
+				props["state"] = *loc.State
+			}
+			if loc.Country != nil {
+				props["country"] = *loc.Country
+			}
+			features = append(features, geoFeature{
+				Type:     "Feature",
+				Geometry: geoPoint{Type: "Point", Coordinates: [2]float64{*loc.Lon, *loc.Lat}},
+				Props:    props,
+			})
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "application/geo+json")
+		writeJSON(w, http.StatusOK, map[string]any{
+			"type":     "FeatureCollection",
+			"features": features,
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/site"
+	"github.com/ShinysArc/photography-portfolio/server/internal/store"
+)
+
+type oembedPhoto struct {
+	Version         string `json:"version"`
+	Type            string `json:"type"`
+	URL             string `json:"url"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+	Title           string `json:"title,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	AuthorURL       string `json:"author_url,omitempty"`
+	ProviderName    string `json:"provider_name,omitempty"`
+	ProviderURL     string `json:"provider_url"`
+	CacheAge        int    `json:"cache_age"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+}
+
+// oembedHandler implements the oEmbed provider side for photo URLs
+// (PUBLIC_URL/?photo=<id>), returning "photo" responses in JSON.
+func oembedHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if f := q.Get("format"); f != "" && f != "json" {
+			http.Error(w, "only json is supported", http.StatusNotImplemented)
+			return
+		}
+		id, ok := photoIDFromURL(cfg, q.Get("url"))
+		if !ok {
+			http.Error(w, "unsupported url", http.StatusNotFound)
+			return
+		}
+		maxW, err1 := optionalInt(q.Get("maxwidth"))
+		maxH, err2 := optionalInt(q.Get("maxheight"))
+		if err1 != nil || err2 != nil {
+			http.Error(w, "maxwidth/maxheight must be positive integers", http.StatusBadRequest)
+			return
+		}
+
+		data, err := cache.Load(cfg)
+		if err != nil {
+			http.Error(w, "cache unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var item *cache.Item
+		for i := range data.Items {
+			if data.Items[i].ID == id {
+				item = &data.Items[i]
+				break
+			}
+		}
+		if item == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		rends := store.Renditions(cfg, id)
+		if len(rends) == 0 {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		pick := pickRendition(rends, maxW, maxH)
+		thumb := fitRendition(rends[len(rends)-1], maxW, maxH)
+		resp := oembedPhoto{
+			Version:         "1.0",
+			Type:            "photo",
+			URL:             cfg.PublicURL + "/photos/" + pick.Path,
+			Width:           pick.Width,
+			Height:          pick.Height,
+			Title:           site.Title(*item),
+			AuthorName:      cfg.AuthorName,
+			ProviderName:    cfg.SiteTitle,
+			ProviderURL:     site.Home(cfg),
+			CacheAge:        3600,
+			ThumbnailURL:    cfg.PublicURL + "/photos/" + thumb.Path,
+			ThumbnailWidth:  thumb.Width,
+			ThumbnailHeight: thumb.Height,
+		}
+		if cfg.AuthorName != "" {
+			resp.AuthorURL = site.Home(cfg)
+		}
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// photoIDFromURL accepts PUBLIC_URL/?photo=<id>, with or without a
+// trailing slash, on the configured host only.
+func photoIDFromURL(cfg config.Config, raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || raw == "" {
+		return "", false
+	}
+	base, err := url.Parse(cfg.PublicURL)
+	if err != nil || !strings.EqualFold(u.Host, base.Host) {
+		return "", false
+	}
+	if strings.TrimSuffix(u.Path, "/") != strings.TrimSuffix(base.Path, "/") {
+		return "", false
+	}
+	id := u.Query().Get("photo")
+	return id, id != ""
+}
+
+// pickRendition returns the largest rendition within the bounds, or the
+// smallest one scaled down to fit when none does. rends is sorted largest
+// first.
+func pickRendition(rends []store.Rendition, maxW, maxH int) store.Rendition {
+	for _, r := range rends {
+		if (maxW == 0 || r.Width <= maxW) && (maxH == 0 || r.Height <= maxH) {
+			return r
+		}
+	}
+	return fitRendition(rends[len(rends)-1], maxW, maxH)
+}
+
+// fitRendition scales the reported size of r down, keeping its aspect
+// ratio, so it never exceeds maxwidth/maxheight as oEmbed requires. The
+// consumer scales the image to the reported size.
+func fitRendition(r store.Rendition, maxW, maxH int) store.Rendition {
+	scale := 1.0
+	if maxW > 0 && r.Width > maxW {
+		scale = float64(maxW) / float64(r.Width)
+	}
+	if maxH > 0 && r.Height > maxH {
+		scale = min(scale, float64(maxH)/float64(r.Height))
+	}
+	if scale < 1 {
+		r.Width = max(1, int(float64(r.Width)*scale+0.5))
+		r.Height = max(1, int(float64(r.Height)*scale+0.5))
+	}
+	return r
+}
+
+func optionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err == nil && n <= 0 {
+		err = strconv.ErrRange
+	}
+	return n, err
+}
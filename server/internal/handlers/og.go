@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/og"
+)
+
+// ogHandler serves 1200x630 share cards: /api/og/album and /api/og/photo/{id}.
+func ogHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := cache.Load(cfg)
+		if err != nil {
+			http.Error(w, "cache unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		var p string
+		if id := r.PathValue("id"); id != "" {
+			p, err = og.PhotoCard(cfg, data, id)
+		} else {
+			p, err = og.AlbumCard(cfg, data)
+		}
+		if errors.Is(err, og.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			log.Printf("og: %v", err)
+			http.Error(w, "render failed", http.StatusInternalServerError)
+			return
+		}
+		serveDocument(w, r, p, "image/jpeg")
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// writeJSON encodes v with status. A Content-Type set by the caller wins.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "encode error", http.StatusInternalServerError)
+	}
+}
+
+// serveDocument serves a generated file with an ETag derived from its
+// content and Last-Modified from its mtime; http.ServeContent answers
+// conditional requests with 304.
+func serveDocument(w http.ResponseWriter, r *http.Request, path, contentType string) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		http.Error(w, "not generated yet", http.StatusServiceUnavailable)
+		return
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		http.Error(w, "not generated yet", http.StatusServiceUnavailable)
+		return
+	}
+	sum := sha256.Sum256(b)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:12])+`"`)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	http.ServeContent(w, r, filepath.Base(path), st.ModTime(), bytes.NewReader(b))
+}
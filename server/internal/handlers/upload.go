@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/inbox"
+)
+
+// formOverhead is allowed on top of the attachment budget for the text fields.
+const formOverhead = 64 << 10
+
+// requestError carries the status a malformed contact request maps to.
+type requestError struct {
+	code int
+	msg  string
+}
+
+func (e *requestError) Error() string { return e.msg }
+
+// readContact decodes a JSON or multipart/form-data contact request. For
+// multipart, files in "attachments" are checked against the count, total
+// size and sniffed-type limits. The returned cleanup closes and removes the
+// uploaded temp files and must be called once the uploads are stored.
+func readContact(cfg config.Config, w http.ResponseWriter, r *http.Request) (contactPayload, []inbox.Upload, func(), error) {
+	var p contactPayload
+	cleanup := func() {}
+
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct != "multipart/form-data" {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, formOverhead)).Decode(&p); err != nil {
+			return p, nil, cleanup, &requestError{400, "bad json"}
+		}
+		return p, nil, cleanup, nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.ContactMaxBytes+formOverhead)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			return p, nil, cleanup, &requestError{http.StatusRequestEntityTooLarge, "attachments too large"}
+		}
+		return p, nil, cleanup, &requestError{400, "bad form"}
+	}
+	form := r.MultipartForm
+	var closers []io.Closer
+	cleanup = func() {
+		for _, c := range closers {
+			_ = c.Close()
+		}
+		_ = form.RemoveAll()
+	}
+
+	p.Name = r.PostFormValue("name")
+	p.Email = r.PostFormValue("email")
+	p.Subject = r.PostFormValue("subject")
+	p.Message = r.PostFormValue("message")
+	p.Captcha = r.PostFormValue("captcha")
+	p.AssetID = r.PostFormValue("assetId")
+	p.Inquiry = r.PostFormValue("inquiry")
+	p.Stamp = r.PostFormValue("stamp")
+	if _, ok := form.Value["hp"]; ok {
+		hp := r.PostFormValue("hp")
+		p.HP = &hp
+	}
+	if s := r.PostFormValue("startedAt"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return p, nil, cleanup, &requestError{400, "startedAt must be epoch milliseconds"}
+		}
+		p.StartedAt = &n
+	}
+
+	files := form.File["attachments"]
+	if len(files) > cfg.ContactMaxFiles {
+		return p, nil, cleanup, &requestError{http.StatusRequestEntityTooLarge, fmt.Sprintf("at most %d attachments", cfg.ContactMaxFiles)}
+	}
+	var total int64
+	uploads := make([]inbox.Upload, 0, len(files))
+	for _, fh := range files {
+		if total += fh.Size; total > cfg.ContactMaxBytes {
+			return p, nil, cleanup, &requestError{http.StatusRequestEntityTooLarge, "attachments too large"}
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return p, nil, cleanup, err
+		}
+		closers = append(closers, f)
+
+		// Trust the bytes, not the extension or the client's Content-Type.
+		head := make([]byte, 512)
+		n, err := io.ReadFull(f, head)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return p, nil, cleanup, err
+		}
+		typ, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+		if !slices.Contains(attachTypes(cfg), typ) {
+			return p, nil, cleanup, &requestError{http.StatusUnsupportedMediaType, fmt.Sprintf("%s: type %s not allowed", fh.Filename, typ)}
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return p, nil, cleanup, err
+		}
+		uploads = append(uploads, inbox.Upload{Name: matchExt(fh.Filename, typ), Type: typ, Data: f})
+	}
+	return p, uploads, cleanup, nil
+}
+
+// preferredExt overrides mime.ExtensionsByType, whose order is arbitrary.
+var preferredExt = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+// matchExt makes the file name end in an extension of its sniffed type, so
+// "photo.png.exe" holding a PNG is mailed as "photo.png.exe.png".
+func matchExt(name, typ string) string {
+	exts, _ := mime.ExtensionsByType(typ)
+	if ext := strings.ToLower(filepath.Ext(name)); ext != "" && (slices.Contains(exts, ext) || preferredExt[typ] == ext) {
+		return name
+	}
+	if ext := preferredExt[typ]; ext != "" {
+		return name + ext
+	}
+	if len(exts) > 0 {
+		return name + exts[0]
+	}
+	return name
+}
+
+func attachTypes(cfg config.Config) []string {
+	var out []string
+	for _, t := range strings.Split(cfg.ContactAttachTypes, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
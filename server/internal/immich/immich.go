@@ -29,6 +29,14 @@ type Exif struct {
 	TimeZone        *string  `json:"timeZone,omitempty"`
 	ExifImageWidth  *int     `json:"exifImageWidth,omitempty"`
 	ExifImageHeight *int     `json:"exifImageHeight,omitempty"`
+	Orientation     *string  `json:"orientation,omitempty"` // EXIF Orientation, "1".."8"
+	Description     *string  `json:"description,omitempty"`
+	Rating          *int     `json:"rating,omitempty"` // 1-5 stars, -1 rejected
+	Latitude        *float64 `json:"latitude,omitempty"`
+	Longitude       *float64 `json:"longitude,omitempty"`
+	City            *string  `json:"city,omitempty"`
+	State           *string  `json:"state,omitempty"`
+	Country         *string  `json:"country,omitempty"`
 }
 
 type Asset struct {
@@ -36,6 +44,7 @@ type Asset struct {
 	OriginalFileName *string `json:"originalFileName,omitempty"`
 	ExifInfo         *Exif   `json:"exifInfo,omitempty"`
 	Tags             []Tag   `json:"tags,omitempty"`
+	IsFavorite       bool    `json:"isFavorite"`
 }
 
 type albumResp struct {
@@ -132,7 +141,7 @@ func GetAsset(ctx context.Context, cfg config.Config, id string) (Asset, error)
 func ImageURL(cfg config.Config, id, q string) string {
 	switch strings.ToLower(q) {
 	case "thumbnail":
-		fallthrough
+		return fmt.Sprintf("%s/api/assets/%s/thumbnail?size=thumbnail", cfg.ImmichURL, id)
 	case "preview":
 		return fmt.Sprintf("%s/api/assets/%s/thumbnail?size=preview", cfg.ImmichURL, id)
 	default:
@@ -0,0 +1,497 @@
+// Package inbox keeps every contact submission on the server.
+//
+// Messages are appended to STATE_DIR/inbox/messages.jsonl, one JSON object
+// per line. index.json maps IDs to byte ranges in that file plus the fields
+// needed for listing, and is the authority for status. Deletes and purges
+// rewrite both files so removed messages do not linger on disk. When the two
+// disagree after a crash or a failed write, the index is rebuilt from the
+// log so no stored message goes missing.
+package inbox
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/fsutil"
+)
+
+type Status string
+
+const (
+	New     Status = "new"
+	Read    Status = "read"
+	Replied Status = "replied"
+	Spam    Status = "spam"
+)
+
+var statuses = []Status{New, Read, Replied, Spam}
+
+// Valid reports whether s is a known status.
+func (s Status) Valid() bool { return slices.Contains(statuses, s) }
+
+var ErrNotFound = errors.New("message not found")
+
+// Message is one contact submission.
+type Message struct {
+	ID       string    `json:"id"`
+	Received time.Time `json:"received"`
+	Status   Status    `json:"status"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Subject  string    `json:"subject,omitempty"`
+	Body     string    `json:"message"`
+	IP       string    `json:"ip,omitempty"`
+	Score    int       `json:"score"`
+	Reasons  []string  `json:"reasons,omitempty"` // spam checks that fired
+	AssetID  string    `json:"assetId,omitempty"` // photo the message is about
+	Inquiry  string    `json:"inquiry,omitempty"` // "print", "license" or "other"
+
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file uploaded with a message, stored under
+// STATE_DIR/inbox/files/<message id>/.
+type Attachment struct {
+	Name string `json:"name"` // as uploaded
+	Type string `json:"type"` // sniffed MIME type
+	Size int64  `json:"size"`
+	File string `json:"file"` // stored name
+}
+
+// Upload is an attachment to store with a new message.
+type Upload struct {
+	Name string
+	Type string
+	Data io.Reader
+}
+
+// entry is the index record for one message.
+type entry struct {
+	Received time.Time `json:"received"`
+	Status   Status    `json:"status"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Subject  string    `json:"subject,omitempty"`
+	Offset   int64     `json:"offset"`
+	Length   int64     `json:"length"`
+}
+
+// Filter selects messages for List.
+type Filter struct {
+	Status Status // empty for all
+	Query  string // case-insensitive substring of name, email, subject or body
+	Offset int
+	Limit  int // 0 for all
+}
+
+// Page is one List result, newest first.
+type Page struct {
+	Total    int       `json:"total"`
+	Messages []Message `json:"messages"`
+}
+
+var mu sync.Mutex
+
+func dir(cfg config.Config) string       { return filepath.Join(cfg.StateDir, "inbox") }
+func logPath(cfg config.Config) string   { return filepath.Join(dir(cfg), "messages.jsonl") }
+func indexPath(cfg config.Config) string { return filepath.Join(dir(cfg), "index.json") }
+
+// Add stores m and its uploads as a new message and returns it with ID,
+// time and attachments set.
+func Add(cfg config.Config, m Message, uploads ...Upload) (Message, error) {
+	var b [6]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return m, err
+	}
+	m.Received = time.Now().UTC()
+	m.ID = m.Received.Format("20060102T150405") + "-" + hex.EncodeToString(b[:])
+	if m.Status == "" {
+		m.Status = New
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	idx, err := readIndex(cfg)
+	if err != nil {
+		return m, err
+	}
+	if m.Attachments, err = saveUploads(cfg, m.ID, uploads); err != nil {
+		removeFiles(cfg, m.ID)
+		return m, err
+	}
+	line, err := json.Marshal(m)
+	if err != nil {
+		return m, err
+	}
+	line = append(line, '\n')
+
+	f, err := os.OpenFile(logPath(cfg), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return m, err
+	}
+	st, err := f.Stat()
+	if err == nil {
+		_, err = f.Write(line)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		removeFiles(cfg, m.ID)
+		return m, err
+	}
+
+	idx[m.ID] = entry{
+		Received: m.Received, Status: m.Status, Name: m.Name, Email: m.Email, Subject: m.Subject,
+		Offset: st.Size(), Length: int64(len(line)),
+	}
+	if err := writeIndex(cfg, idx); err != nil {
+		// Take the line back out so the message is not half stored. If
+		// that fails too, the next read rebuilds the index and keeps it.
+		if terr := os.Truncate(logPath(cfg), st.Size()); terr == nil {
+			removeFiles(cfg, m.ID)
+		}
+		return m, err
+	}
+	return m, nil
+}
+
+// Get returns one message with its current status.
+func Get(cfg config.Config, id string) (Message, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	idx, err := readIndex(cfg)
+	if err != nil {
+		return Message{}, err
+	}
+	e, ok := idx[id]
+	if !ok {
+		return Message{}, ErrNotFound
+	}
+	f, err := os.Open(logPath(cfg))
+	if err != nil {
+		return Message{}, err
+	}
+	defer func() { _ = f.Close() }()
+	return readAt(f, id, e)
+}
+
+// List returns messages matching f, newest first.
+func List(cfg config.Config, f Filter) (Page, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	idx, err := readIndex(cfg)
+	if err != nil {
+		return Page{}, err
+	}
+
+	var all []Message
+	if err := scan(cfg, idx, func(m Message) { all = append(all, m) }); err != nil {
+		return Page{}, err
+	}
+	q := strings.ToLower(strings.TrimSpace(f.Query))
+	out := all[:0]
+	for _, m := range all {
+		if f.Status != "" && m.Status != f.Status {
+			continue
+		}
+		if q != "" && !matches(m, q) {
+			continue
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Received.After(out[j].Received) })
+
+	p := Page{Total: len(out), Messages: []Message{}}
+	if f.Offset < len(out) {
+		out = out[f.Offset:]
+		if f.Limit > 0 && len(out) > f.Limit {
+			out = out[:f.Limit]
+		}
+		p.Messages = out
+	}
+	return p, nil
+}
+
+// Mark sets the status of one message.
+func Mark(cfg config.Config, id string, s Status) error {
+	if !s.Valid() {
+		return fmt.Errorf("unknown status %q", s)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	idx, err := readIndex(cfg)
+	if err != nil {
+		return err
+	}
+	e, ok := idx[id]
+	if !ok {
+		return ErrNotFound
+	}
+	e.Status = s
+	idx[id] = e
+	return writeIndex(cfg, idx)
+}
+
+// Delete removes a message and compacts the log.
+func Delete(cfg config.Config, id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	idx, err := readIndex(cfg)
+	if err != nil {
+		return err
+	}
+	if _, ok := idx[id]; !ok {
+		return ErrNotFound
+	}
+	delete(idx, id)
+	if err := compact(cfg, idx); err != nil {
+		return err
+	}
+	removeFiles(cfg, id)
+	return nil
+}
+
+// AttachmentPath is where a stored attachment lives on disk.
+func AttachmentPath(cfg config.Config, id string, a Attachment) string {
+	return filepath.Join(filesDir(cfg, id), a.File)
+}
+
+func filesDir(cfg config.Config, id string) string {
+	return filepath.Join(dir(cfg), "files", id)
+}
+
+func saveUploads(cfg config.Config, id string, uploads []Upload) ([]Attachment, error) {
+	if len(uploads) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(filesDir(cfg, id), 0o700); err != nil {
+		return nil, err
+	}
+	out := make([]Attachment, 0, len(uploads))
+	for i, u := range uploads {
+		a := Attachment{Name: u.Name, Type: u.Type, File: strconv.Itoa(i+1) + "-" + safeName(u.Name)}
+		f, err := os.OpenFile(AttachmentPath(cfg, id, a), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		a.Size, err = io.Copy(f, u.Data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func removeFiles(cfg config.Config, id string) {
+	_ = os.RemoveAll(filesDir(cfg, id))
+}
+
+// safeName keeps letters, digits, dots, dashes and underscores.
+func safeName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	name = strings.TrimLeft(string(b), ".")
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+	if name == "" {
+		name = "file"
+	}
+	return name
+}
+
+func matches(m Message, q string) bool {
+	for _, s := range []string{m.Name, m.Email, m.Subject, m.Body} {
+		if strings.Contains(strings.ToLower(s), q) {
+			return true
+		}
+	}
+	return false
+}
+
+func readAt(f *os.File, id string, e entry) (Message, error) {
+	var m Message
+	b := make([]byte, e.Length)
+	if _, err := f.ReadAt(b, e.Offset); err != nil {
+		return m, fmt.Errorf("read %s: %w", id, err)
+	}
+	if err := json.Unmarshal(b, &m); err != nil || m.ID != id {
+		return m, fmt.Errorf("read %s: index out of sync", id)
+	}
+	m.Status = e.Status
+	return m, nil
+}
+
+// scan calls fn for every indexed message in log order.
+func scan(cfg config.Config, idx map[string]entry, fn func(Message)) error {
+	f, err := os.Open(logPath(cfg))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var m Message
+			if json.Unmarshal(line, &m) == nil {
+				if e, ok := idx[m.ID]; ok {
+					m.Status = e.Status
+					fn(m)
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// compact rewrites the log with only the messages in idx, refreshing offsets.
+func compact(cfg config.Config, idx map[string]entry) error {
+	var buf bytes.Buffer
+	next := make(map[string]entry, len(idx))
+	var scanErr error
+	err := scan(cfg, idx, func(m Message) {
+		line, err := json.Marshal(m)
+		if err != nil {
+			scanErr = err
+			return
+		}
+		e := idx[m.ID]
+		e.Offset, e.Length = int64(buf.Len()), int64(len(line)+1)
+		next[m.ID] = e
+		buf.Write(line)
+		buf.WriteByte('\n')
+	})
+	if err == nil {
+		err = scanErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteAtomic(logPath(cfg), buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return writeIndex(cfg, next)
+}
+
+// rebuild re-derives the index from the log: every complete line becomes an
+// entry, keeping its status from old when it is there. Torn lines are dropped
+// by the compaction that follows.
+func rebuild(cfg config.Config, old map[string]entry) (map[string]entry, error) {
+	idx := map[string]entry{}
+	f, err := os.Open(logPath(cfg))
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, writeIndex(cfg, idx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		var m Message
+		if len(line) > 0 && line[len(line)-1] == '\n' && json.Unmarshal(line, &m) == nil && m.ID != "" {
+			e := entry{Received: m.Received, Status: m.Status, Name: m.Name, Email: m.Email, Subject: m.Subject}
+			if o, ok := old[m.ID]; ok {
+				e.Status = o.Status
+			}
+			idx[m.ID] = e
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := compact(cfg, idx); err != nil {
+		return nil, err
+	}
+	log.Printf("inbox: index out of step with the log, rebuilt with %d messages", len(idx))
+	return readIndexFile(cfg)
+}
+
+// readIndex loads the index, rebuilding it when it does not cover the log
+// exactly.
+func readIndex(cfg config.Config) (map[string]entry, error) {
+	idx, err := readIndexFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var covered int64
+	for _, e := range idx {
+		covered += e.Length
+	}
+	var size int64
+	if st, err := os.Stat(logPath(cfg)); err == nil {
+		size = st.Size()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if size == covered {
+		return idx, nil
+	}
+	return rebuild(cfg, idx)
+}
+
+func readIndexFile(cfg config.Config) (map[string]entry, error) {
+	if err := os.MkdirAll(dir(cfg), 0o700); err != nil {
+		return nil, err
+	}
+	idx := map[string]entry{}
+	b, err := os.ReadFile(indexPath(cfg))
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return idx, json.Unmarshal(b, &idx)
+}
+
+func writeIndex(cfg config.Config, idx map[string]entry) error {
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteAtomic(indexPath(cfg), b, 0o600)
+}
@@ -0,0 +1,67 @@
+package inbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/mail"
+)
+
+// Purge deletes messages older than the configured retention: spam after
+// cfg.InboxSpamDays, everything else after cfg.InboxRetentionDays. A zero
+// setting keeps that class forever. It returns how many were removed.
+func Purge(cfg config.Config, now time.Time) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	idx, err := readIndex(cfg)
+	if err != nil {
+		return 0, err
+	}
+	var expired []string
+	for id, e := range idx {
+		days := cfg.InboxRetentionDays
+		if e.Status == Spam {
+			days = cfg.InboxSpamDays
+		}
+		if days > 0 && now.Sub(e.Received) > time.Duration(days)*24*time.Hour {
+			delete(idx, id)
+			expired = append(expired, id)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	if err := compact(cfg, idx); err != nil {
+		return 0, err
+	}
+	for _, id := range expired {
+		removeFiles(cfg, id)
+	}
+	return len(expired), nil
+}
+
+// RunRetention purges expired messages, and queued mail past the same
+// retention, at startup and then daily. It blocks until ctx is done.
+func RunRetention(ctx context.Context, cfg config.Config) {
+	tick := time.NewTicker(24 * time.Hour)
+	defer tick.Stop()
+	for {
+		if n, err := Purge(cfg, time.Now()); err != nil {
+			log.Printf("inbox: purge FAILED: %v", err)
+		} else if n > 0 {
+			log.Printf("inbox: purged %d expired message(s)", n)
+		}
+		if n, err := mail.Purge(cfg, time.Now()); err != nil {
+			log.Printf("mailq: purge FAILED: %v", err)
+		} else if n > 0 {
+			log.Printf("mailq: purged %d expired message(s)", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
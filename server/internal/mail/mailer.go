@@ -1,10 +1,11 @@
 package mail
 
 import (
+	"cmp"
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	gomail "github.com/wneessen/go-mail"
@@ -13,42 +14,55 @@ import (
 )
 
 type Mailer struct {
-	client *gomail.Client
-	from   string
-	to     string
+	transport Transport
+	from      string
+	to        string
 }
 
+// NewMailer builds a Mailer on the transport selected by MAIL_TRANSPORT.
+// The local transports fall back to placeholder addresses so the contact
+// flow works without any mail configuration.
 func NewMailer(cfg config.Config) (*Mailer, error) {
-	if cfg.SMTPHost == "" || cfg.SMTPUser == "" || cfg.SMTPPass == "" || cfg.ContactFrom == "" || cfg.ContactTo == "" {
-		return nil, errors.New("SMTP/Contact env not fully configured")
+	t, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
 	}
-
-	opts := []gomail.Option{
-		gomail.WithPort(cfg.SMTPPort),
-		gomail.WithSMTPAuth(gomail.SMTPAuthPlain),
-		gomail.WithUsername(cfg.SMTPUser),
-		gomail.WithPassword(cfg.SMTPPass),
-		gomail.WithTimeout(20 * time.Second),
-
-		// TLS setup
-		gomail.WithTLSPolicy(gomail.TLSMandatory),
-		gomail.WithTLSConfig(&tls.Config{ServerName: cfg.SMTPHost}),
+	from, to := cfg.ContactFrom, cfg.ContactTo
+	if t.Name() != "smtp" {
+		from = cmp.Or(from, "Portfolio <no-reply@localhost>")
+		to = cmp.Or(to, "owner@localhost")
 	}
-
-	// If using implicit TLS (465), enable SSL mode
-	if cfg.SMTPPort == 465 {
-		opts = append(opts, gomail.WithSSL())
+	if from == "" || to == "" {
+		return nil, errors.New("CONTACT_FROM/CONTACT_TO not configured")
 	}
+	return New(t, from, to), nil
+}
 
-	c, err := gomail.NewClient(cfg.SMTPHost, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return &Mailer{client: c, from: cfg.ContactFrom, to: cfg.ContactTo}, nil
+// New builds a Mailer on any transport; to is the site owner.
+func New(t Transport, from, to string) *Mailer {
+	return &Mailer{transport: t, from: from, to: to}
 }
 
+// Transport names the delivery backend.
+func (m *Mailer) Transport() string {
+	return m.transport.Name()
+}
+
+// Send mails the site owner.
 func (m *Mailer) Send(ctx context.Context, replyTo, subject, text, html string) error {
-	if m == nil || m.client == nil {
+	return m.SendTo(ctx, "", replyTo, subject, text, html)
+}
+
+// Attachment is a file on disk sent along with a message.
+type Attachment struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// SendTo mails to, or the site owner when to is empty.
+func (m *Mailer) SendTo(ctx context.Context, to, replyTo, subject, text, html string, atts ...Attachment) error {
+	if m == nil || m.transport == nil {
 		return errors.New("mailer not initialized")
 	}
 
@@ -56,13 +70,18 @@ func (m *Mailer) Send(ctx context.Context, replyTo, subject, text, html string)
 	if err := msg.From(m.from); err != nil {
 		return fmt.Errorf("from: %w", err)
 	}
-	if err := msg.To(m.to); err != nil {
+	if to == "" {
+		to = m.to
+	}
+	if err := msg.To(to); err != nil {
 		return fmt.Errorf("to: %w", err)
 	}
 	if replyTo != "" {
 		msg.SetGenHeader("Reply-To", replyTo)
 	}
 	msg.Subject(subject)
+	msg.SetDate()
+	msg.SetMessageID()
 
 	if html != "" {
 		msg.SetBodyString(gomail.TypeTextPlain, text)
@@ -70,9 +89,17 @@ func (m *Mailer) Send(ctx context.Context, replyTo, subject, text, html string)
 	} else {
 		msg.SetBodyString(gomail.TypeTextPlain, text)
 	}
+	for _, a := range atts {
+		// AttachFile silently skips unreadable files; fail instead so the
+		// queue retries rather than sending without them.
+		if _, err := os.Stat(a.Path); err != nil {
+			return fmt.Errorf("attachment %s: %w", a.Name, err)
+		}
+		msg.AttachFile(a.Path, gomail.WithFileName(a.Name), gomail.WithFileContentType(gomail.ContentType(a.Type)))
+	}
 
 	ctx, cancel := context.WithTimeout(ctx, 25*time.Second)
 	defer cancel()
 
-	return m.client.DialAndSendWithContext(ctx, msg)
+	return m.transport.Deliver(ctx, msg)
 }
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSendToLog(t *testing.T) {
+	var buf bytes.Buffer
+	m := New(NewLog(&buf), "Site <site@example.org>", "owner@example.org")
+
+	att := filepath.Join(t.TempDir(), "photo.png")
+	if err := os.WriteFile(att, []byte("\x89PNG\r\n\x1a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := m.SendTo(context.Background(), "", "visitor@example.net", "Hello", "plain body", "<p>html body</p>",
+		Attachment{Name: "photo.png", Type: "image/png", Path: att})
+	if err != nil {
+		t.Fatalf("SendTo: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"To: <owner@example.org>",
+		"Reply-To: visitor@example.net",
+		"Subject: Hello",
+		"plain body",
+		"<p>html body</p>",
+		`filename="photo.png"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("message lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSendToMissingAttachment(t *testing.T) {
+	var buf bytes.Buffer
+	m := New(NewLog(&buf), "site@example.org", "owner@example.org")
+	err := m.SendTo(context.Background(), "", "", "Hello", "body", "",
+		Attachment{Name: "gone.pdf", Type: "application/pdf", Path: filepath.Join(t.TempDir(), "gone.pdf")})
+	if err == nil {
+		t.Fatal("SendTo succeeded without the attachment file")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("message delivered despite the error:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,394 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/fsutil"
+)
+
+// The outbound queue lives under STATE_DIR rather than DATA_DIR: DATA_DIR is
+// served publicly by the web app and queued messages hold visitors' addresses.
+// Each message is one JSON file, in pending/ until it is delivered (and
+// removed) or dead/ once it has failed cfg.MailMaxAttempts times. Its
+// attachments are copied to files/<id>/ so deleting the inbox record cannot
+// break a queued mail; they go away with the message.
+const (
+	pendingDir = "pending"
+	deadDir    = "dead"
+	filesDir   = "files"
+
+	retryBase = time.Minute
+	retryMax  = 6 * time.Hour
+)
+
+var ErrNotFound = errors.New("message not found")
+
+// Message is one queued email.
+type Message struct {
+	ID          string       `json:"id"`
+	To          string       `json:"to,omitempty"` // empty for the site owner
+	ReplyTo     string       `json:"replyTo,omitempty"`
+	Subject     string       `json:"subject"`
+	Text        string       `json:"text"`
+	HTML        string       `json:"html,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Created     time.Time    `json:"created"`
+	Attempts    int          `json:"attempts"`
+	NextTry     time.Time    `json:"nextTry"`
+	LastError   string       `json:"lastError,omitempty"`
+	Dead        bool         `json:"dead,omitempty"`
+}
+
+// Queued lists pending and dead-lettered messages, oldest first.
+type Queued struct {
+	Pending []Message `json:"pending"`
+	Dead    []Message `json:"dead"`
+}
+
+// qmu serializes file moves between request handlers and the worker.
+var qmu sync.Mutex
+
+// wake nudges the worker when a message becomes due early.
+var wake = make(chan struct{}, 1)
+
+func notifyWorker() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+func queueDir(cfg config.Config, sub string) string {
+	return filepath.Join(cfg.StateDir, "mailq", sub)
+}
+
+func msgPath(cfg config.Config, sub, id string) string {
+	return filepath.Join(queueDir(cfg, sub), id+".json")
+}
+
+// Enqueue persists m and copies of its attachments (fsynced) before
+// returning, so an accepted contact message survives SMTP outages and
+// restarts.
+func Enqueue(cfg config.Config, m Message) (Message, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return m, err
+	}
+	now := time.Now().UTC()
+	m.ID = now.Format("20060102T150405") + "-" + hex.EncodeToString(b[:])
+	m.Created, m.NextTry = now, now
+	m.Attempts, m.LastError, m.Dead = 0, "", false
+
+	atts, err := copyAttachments(cfg, m.ID, m.Attachments)
+	if err != nil {
+		removeFiles(cfg, m.ID)
+		return m, err
+	}
+	m.Attachments = atts
+
+	qmu.Lock()
+	err = save(cfg, pendingDir, m)
+	qmu.Unlock()
+	if err != nil {
+		removeFiles(cfg, m.ID)
+		return m, err
+	}
+	notifyWorker()
+	return m, nil
+}
+
+// List returns the queue contents.
+func List(cfg config.Config) (Queued, error) {
+	qmu.Lock()
+	defer qmu.Unlock()
+	var q Queued
+	var err error
+	if q.Pending, err = readDir(cfg, pendingDir); err != nil {
+		return q, err
+	}
+	q.Dead, err = readDir(cfg, deadDir)
+	return q, err
+}
+
+// Resend makes a pending or dead message due now with a fresh attempt budget.
+func Resend(cfg config.Config, id string) (Message, error) {
+	qmu.Lock()
+	defer qmu.Unlock()
+	m, sub, err := find(cfg, id)
+	if err != nil {
+		return m, err
+	}
+	m.Attempts, m.Dead, m.NextTry = 0, false, time.Now().UTC()
+	if err := save(cfg, pendingDir, m); err != nil {
+		return m, err
+	}
+	if sub == deadDir {
+		_ = os.Remove(msgPath(cfg, deadDir, id))
+	}
+	notifyWorker()
+	return m, nil
+}
+
+// Discard deletes a message from either list.
+func Discard(cfg config.Config, id string) error {
+	qmu.Lock()
+	defer qmu.Unlock()
+	_, sub, err := find(cfg, id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(msgPath(cfg, sub, id)); err != nil {
+		return err
+	}
+	removeFiles(cfg, id)
+	return nil
+}
+
+// Purge removes pending and dead messages older than
+// cfg.InboxRetentionDays. Queued mail holds the same visitor data as the
+// inbox, so it follows the same retention; zero keeps it forever.
+func Purge(cfg config.Config, now time.Time) (int, error) {
+	if cfg.InboxRetentionDays <= 0 {
+		return 0, nil
+	}
+	cutoff := now.Add(-time.Duration(cfg.InboxRetentionDays) * 24 * time.Hour)
+	qmu.Lock()
+	defer qmu.Unlock()
+	n := 0
+	for _, sub := range []string{pendingDir, deadDir} {
+		msgs, err := readDir(cfg, sub)
+		if err != nil {
+			return n, err
+		}
+		for _, m := range msgs {
+			if !m.Created.Before(cutoff) {
+				continue
+			}
+			if err := os.Remove(msgPath(cfg, sub, m.ID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return n, err
+			}
+			removeFiles(cfg, m.ID)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// RunQueue delivers pending messages through m, retrying failures with
+// exponential backoff. It blocks until ctx is done.
+func RunQueue(ctx context.Context, cfg config.Config, m *Mailer) {
+	for {
+		next := deliverDue(ctx, cfg, m)
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = min(time.Until(next), time.Hour)
+		}
+		timer := time.NewTimer(max(wait, time.Second))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// deliverDue sends every due message and returns the earliest future retry.
+func deliverDue(ctx context.Context, cfg config.Config, m *Mailer) time.Time {
+	qmu.Lock()
+	pending, err := readDir(cfg, pendingDir)
+	qmu.Unlock()
+	if err != nil {
+		log.Printf("mailq: %v", err)
+		return time.Now().Add(retryBase)
+	}
+
+	var next time.Time
+	for _, msg := range pending {
+		if ctx.Err() != nil {
+			return next
+		}
+		if time.Now().Before(msg.NextTry) {
+			if next.IsZero() || msg.NextTry.Before(next) {
+				next = msg.NextTry
+			}
+			continue
+		}
+
+		sendErr := m.SendTo(ctx, msg.To, msg.ReplyTo, msg.Subject, msg.Text, msg.HTML, msg.Attachments...)
+
+		qmu.Lock()
+		cur, sub, err := find(cfg, msg.ID)
+		switch {
+		case err != nil || sub != pendingDir:
+			// discarded or moved by an admin while sending
+		case sendErr == nil:
+			if err := os.Remove(msgPath(cfg, pendingDir, msg.ID)); err != nil {
+				log.Printf("mailq: %s sent but not removed: %v", msg.ID, err)
+			} else {
+				removeFiles(cfg, msg.ID)
+			}
+			log.Printf("mailq: %s sent after %d attempt(s)", msg.ID, cur.Attempts+1)
+		default:
+			cur.Attempts++
+			cur.LastError = sendErr.Error()
+			if cur.Attempts >= cfg.MailMaxAttempts {
+				cur.Dead = true
+				if err := save(cfg, deadDir, cur); err == nil {
+					_ = os.Remove(msgPath(cfg, pendingDir, cur.ID))
+				}
+				log.Printf("mailq: %s dead after %d attempts: %v", cur.ID, cur.Attempts, sendErr)
+			} else {
+				cur.NextTry = time.Now().UTC().Add(backoff(cur.Attempts))
+				if err := save(cfg, pendingDir, cur); err != nil {
+					log.Printf("mailq: %s: %v", cur.ID, err)
+				}
+				log.Printf("mailq: %s attempt %d failed, retry at %s: %v", cur.ID, cur.Attempts, cur.NextTry.Format(time.RFC3339), sendErr)
+				if next.IsZero() || cur.NextTry.Before(next) {
+					next = cur.NextTry
+				}
+			}
+		}
+		qmu.Unlock()
+	}
+	return next
+}
+
+// backoff doubles from retryBase per failed attempt, capped at retryMax.
+func backoff(attempts int) time.Duration {
+	d := retryBase
+	for i := 1; i < attempts && d < retryMax; i++ {
+		d *= 2
+	}
+	return min(d, retryMax)
+}
+
+func find(cfg config.Config, id string) (Message, string, error) {
+	var m Message
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return m, "", ErrNotFound
+	}
+	for _, sub := range []string{pendingDir, deadDir} {
+		b, err := os.ReadFile(msgPath(cfg, sub, id))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return m, "", err
+		}
+		return m, sub, json.Unmarshal(b, &m)
+	}
+	return m, "", ErrNotFound
+}
+
+func readDir(cfg config.Config, sub string) ([]Message, error) {
+	entries, err := os.ReadDir(queueDir(cfg, sub))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Message{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Message, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(queueDir(cfg, sub), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var m Message
+		if err := json.Unmarshal(b, &m); err != nil {
+			log.Printf("mailq: skipping %s: %v", e.Name(), err)
+			continue
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out, nil
+}
+
+// copyAttachments copies atts into files/<id>/ and returns them pointing at
+// the copies.
+func copyAttachments(cfg config.Config, id string, atts []Attachment) ([]Attachment, error) {
+	if len(atts) == 0 {
+		return atts, nil
+	}
+	dir := filepath.Join(queueDir(cfg, filesDir), id)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	out := make([]Attachment, len(atts))
+	for i, a := range atts {
+		dst := filepath.Join(dir, strconv.Itoa(i+1))
+		if err := copyFile(a.Path, dst); err != nil {
+			return nil, fmt.Errorf("queue attachment %q: %w", a.Name, err)
+		}
+		a.Path = dst
+		out[i] = a
+	}
+	return out, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// removeFiles deletes the attachment copies of message id, if any.
+func removeFiles(cfg config.Config, id string) {
+	if err := os.RemoveAll(filepath.Join(queueDir(cfg, filesDir), id)); err != nil {
+		log.Printf("mailq: %s: remove attachments: %v", id, err)
+	}
+}
+
+// save writes m durably with fsutil.WriteAtomic.
+func save(cfg config.Config, sub string, m Message) error {
+	dir := queueDir(cfg, sub)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteAtomic(msgPath(cfg, sub, m.ID), b, 0o600); err != nil {
+		return fmt.Errorf("queue %s: %w", m.ID, err)
+	}
+	return nil
+}
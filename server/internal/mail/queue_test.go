@@ -0,0 +1,119 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gomail "github.com/wneessen/go-mail"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+// failing is a transport that is always down.
+type failing struct{}
+
+func (failing) Name() string { return "failing" }
+
+func (failing) Deliver(context.Context, *gomail.Msg) error { return errors.New("connection refused") }
+
+func TestQueueDelivers(t *testing.T) {
+	cfg := config.Config{StateDir: t.TempDir(), MailMaxAttempts: 3}
+	var buf bytes.Buffer
+	m := New(NewLog(&buf), "site@example.org", "owner@example.org")
+
+	if _, err := Enqueue(cfg, Message{To: "visitor@example.net", Subject: "Thanks", Text: "received"}); err != nil {
+		t.Fatal(err)
+	}
+	if next := deliverDue(context.Background(), cfg, m); !next.IsZero() {
+		t.Errorf("next retry = %v, want none", next)
+	}
+
+	if !strings.Contains(buf.String(), "To: <visitor@example.net>") || !strings.Contains(buf.String(), "Subject: Thanks") {
+		t.Errorf("unexpected delivery:\n%s", buf.String())
+	}
+	q, err := List(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Pending) != 0 || len(q.Dead) != 0 {
+		t.Errorf("queue not empty after delivery: %+v", q)
+	}
+}
+
+func TestQueueKeepsAttachments(t *testing.T) {
+	cfg := config.Config{StateDir: t.TempDir(), MailMaxAttempts: 3}
+	src := filepath.Join(t.TempDir(), "upload")
+	if err := os.WriteFile(src, []byte("%PDF-1.7"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	queued, err := Enqueue(cfg, Message{Subject: "Files", Text: "see attached", Attachments: []Attachment{{Name: "brief.pdf", Type: "application/pdf", Path: src}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The inbox copy may be deleted before the queue gets to the message.
+	if err := os.Remove(src); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	deliverDue(context.Background(), cfg, New(NewLog(&buf), "site@example.org", "owner@example.org"))
+
+	if !strings.Contains(buf.String(), `filename="brief.pdf"`) {
+		t.Errorf("attachment missing from delivery:\n%s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(queueDir(cfg, filesDir), queued.ID)); !os.IsNotExist(err) {
+		t.Errorf("queued copy not removed after delivery: %v", err)
+	}
+}
+
+func TestQueueRetriesThenDeadLetters(t *testing.T) {
+	cfg := config.Config{StateDir: t.TempDir(), MailMaxAttempts: 2}
+	m := New(failing{}, "site@example.org", "owner@example.org")
+
+	if _, err := Enqueue(cfg, Message{Subject: "Hello", Text: "body"}); err != nil {
+		t.Fatal(err)
+	}
+
+	next := deliverDue(context.Background(), cfg, m)
+	if d := time.Until(next); d <= 0 || d > retryBase {
+		t.Errorf("first retry in %v, want within %v", d, retryBase)
+	}
+	q, _ := List(cfg)
+	if len(q.Pending) != 1 || q.Pending[0].Attempts != 1 || q.Pending[0].LastError == "" {
+		t.Fatalf("after one failure: %+v", q)
+	}
+
+	// Make it due again; the second failure exhausts MailMaxAttempts.
+	q.Pending[0].NextTry = time.Now()
+	if err := save(cfg, pendingDir, q.Pending[0]); err != nil {
+		t.Fatal(err)
+	}
+	deliverDue(context.Background(), cfg, m)
+
+	q, _ = List(cfg)
+	if len(q.Pending) != 0 || len(q.Dead) != 1 || !q.Dead[0].Dead {
+		t.Fatalf("after max attempts: %+v", q)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{20, retryMax},
+	} {
+		if got := backoff(tc.attempts); got != tc.want {
+			t.Errorf("backoff(%d) = %v, want %v", tc.attempts, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+// Built-in mail bodies. Each message has a <name>.txt, which also defines
+// the "subject" template, and an optional <name>.html; locale variants are
+// named <name>.<lang>.txt/.html (e.g. confirm.fr.txt). Files with the same
+// name in CONTENT_DIR/mail take precedence, one file at a time.
+//
+//go:embed templates/*.txt templates/*.html
+var builtin embed.FS
+
+// Template names.
+const (
+	Notify  = "notify"  // owner notification for a contact message
+	Confirm = "confirm" // confirmation sent to the visitor
+)
+
+// Rendered is a message ready to send.
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string // empty when the chosen locale has no HTML variant
+	Locale  string // "" for the default variant
+}
+
+// Render executes template name with data, choosing the first locale from
+// acceptLanguage that has a text variant and falling back to the default.
+func Render(cfg config.Config, name, acceptLanguage string, data any) (Rendered, error) {
+	var out Rendered
+	for _, loc := range append(Locales(acceptLanguage), "") {
+		base := name
+		if loc != "" {
+			base += "." + loc
+		}
+		src, err := readTemplate(cfg, base+".txt")
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return out, err
+		}
+		out.Locale = loc
+
+		tt, err := texttemplate.New(base).Parse(src)
+		if err != nil {
+			return out, err
+		}
+		var buf bytes.Buffer
+		if err := tt.Execute(&buf, data); err != nil {
+			return out, err
+		}
+		out.Text = strings.TrimSpace(buf.String()) + "\n"
+		buf.Reset()
+		if tt.Lookup("subject") != nil {
+			if err := tt.ExecuteTemplate(&buf, "subject", data); err != nil {
+				return out, err
+			}
+			// Subjects are single header lines.
+			out.Subject = strings.Join(strings.Fields(buf.String()), " ")
+		}
+
+		src, err = readTemplate(cfg, base+".html")
+		if errors.Is(err, fs.ErrNotExist) {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		ht, err := htmltemplate.New(base).Parse(src)
+		if err != nil {
+			return out, err
+		}
+		buf.Reset()
+		if err := ht.Execute(&buf, data); err != nil {
+			return out, err
+		}
+		out.HTML = buf.String()
+		return out, nil
+	}
+	return out, errors.New("no template named " + name)
+}
+
+func readTemplate(cfg config.Config, file string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(cfg.ContentDir, "mail", file))
+	if errors.Is(err, fs.ErrNotExist) {
+		b, err = builtin.ReadFile("templates/" + file)
+	}
+	return string(b), err
+}
+
+// Locales turns an Accept-Language header into lowercase candidates by
+// preference, each region tag followed by its base language:
+// "fr-CH, fr;q=0.9, en;q=0.8" -> fr-ch, fr, en.
+func Locales(header string) []string {
+	type pref struct {
+		tag string
+		q   float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" || strings.ContainsAny(tag, `/\.`) {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			prefs = append(prefs, pref{tag, q})
+		}
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	var out []string
+	seen := map[string]bool{}
+	add := func(s string) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	for _, p := range prefs {
+		add(p.tag)
+		if base, _, ok := strings.Cut(p.tag, "-"); ok {
+			add(base)
+		}
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gomail "github.com/wneessen/go-mail"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+// Transport delivers a composed message.
+type Transport interface {
+	Deliver(ctx context.Context, msg *gomail.Msg) error
+	Name() string
+}
+
+// NewTransport builds the transport named by cfg.MailTransport.
+func NewTransport(cfg config.Config) (Transport, error) {
+	switch cfg.MailTransport {
+	case "smtp":
+		return NewSMTP(cfg)
+	case "file":
+		return NewMaildir(cfg.MailDir), nil
+	case "log":
+		return NewLog(os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT %q (smtp, file, log)", cfg.MailTransport)
+	}
+}
+
+// SMTP sends through the configured server with go-mail.
+type SMTP struct {
+	client *gomail.Client
+}
+
+func NewSMTP(cfg config.Config) (*SMTP, error) {
+	if cfg.SMTPHost == "" || cfg.SMTPUser == "" || cfg.SMTPPass == "" {
+		return nil, errors.New("SMTP env not fully configured")
+	}
+
+	opts := []gomail.Option{
+		gomail.WithPort(cfg.SMTPPort),
+		gomail.WithSMTPAuth(gomail.SMTPAuthPlain),
+		gomail.WithUsername(cfg.SMTPUser),
+		gomail.WithPassword(cfg.SMTPPass),
+		gomail.WithTimeout(20 * time.Second),
+
+		// TLS setup
+		gomail.WithTLSPolicy(gomail.TLSMandatory),
+		gomail.WithTLSConfig(&tls.Config{ServerName: cfg.SMTPHost}),
+	}
+
+	// If using implicit TLS (465), enable SSL mode
+	if cfg.SMTPPort == 465 {
+		opts = append(opts, gomail.WithSSL())
+	}
+
+	c, err := gomail.NewClient(cfg.SMTPHost, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTP{client: c}, nil
+}
+
+func (t *SMTP) Name() string { return "smtp" }
+
+func (t *SMTP) Deliver(ctx context.Context, msg *gomail.Msg) error {
+	return t.client.DialAndSendWithContext(ctx, msg)
+}
+
+// Maildir writes each message as an .eml file into a Maildir (tmp/, new/,
+// cur/), readable by mail clients and easy to inspect in development.
+type Maildir struct {
+	Dir string
+}
+
+func NewMaildir(dir string) *Maildir { return &Maildir{Dir: dir} }
+
+func (t *Maildir) Name() string { return "file" }
+
+func (t *Maildir) Deliver(_ context.Context, msg *gomail.Msg) error {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(t.Dir, sub), 0o700); err != nil {
+			return err
+		}
+	}
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	host = strings.NewReplacer("/", "_", ":", "_").Replace(host)
+	name := strconv.FormatInt(time.Now().UnixNano(), 10) + "." + strconv.Itoa(os.Getpid()) + "_" + hex.EncodeToString(b[:]) + "." + host + ".eml"
+
+	tmp := filepath.Join(t.Dir, "tmp", name)
+	if err := msg.WriteToFile(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.Dir, "new", name))
+}
+
+// Log writes the full message to w, for development and tests.
+type Log struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLog(w io.Writer) *Log { return &Log{w: w} }
+
+func (t *Log) Name() string { return "log" }
+
+func (t *Log) Deliver(_ context.Context, msg *gomail.Msg) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := io.WriteString(t.w, "----- mail -----\r\n"); err != nil {
+		return err
+	}
+	if _, err := msg.WriteTo(t.w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(t.w, "\r\n----- end -----\r\n")
+	return err
+}
@@ -25,7 +25,7 @@ func WithCORS(allowed string, next http.Handler) http.Handler {
 			reqHdrs = "Content-Type, X-Admin-Token"
 		}
 		w.Header().Set("Access-Control-Allow-Headers", reqHdrs)
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
 		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Content-Type, Content-Length")
 		w.Header().Set("Access-Control-Max-Age", "600")
 
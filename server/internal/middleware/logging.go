@@ -3,9 +3,10 @@ package middleware
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 	"log"
-	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	"time"
 )
@@ -61,19 +62,102 @@ func WithLogging(next http.Handler) http.Handler {
 	})
 }
 
-func clientIP(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		parts := strings.Split(xff, ",")
-		return strings.TrimSpace(parts[0])
+// trustedProxies are the peers whose X-Forwarded-For and X-Real-IP are
+// believed. Set once at startup by SetTrustedProxies.
+var trustedProxies []netip.Prefix
+
+// ParseTrustedProxies reads comma-separated IPs or CIDRs. "none" trusts
+// no peer, so forwarding headers are always ignored.
+func ParseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" || e == "none" {
+			continue
+		}
+		if !strings.Contains(e, "/") {
+			a, err := netip.ParseAddr(e)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %v", e, err)
+			}
+			out = append(out, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(e)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %v", e, err)
+		}
+		out = append(out, p.Masked())
 	}
-	if xrip := r.Header.Get("X-Real-IP"); xrip != "" {
-		return strings.TrimSpace(xrip)
+	return out, nil
+}
+
+// SetTrustedProxies must be called before serving.
+func SetTrustedProxies(p []netip.Prefix) {
+	trustedProxies = p
+}
+
+func trusted(a netip.Addr) bool {
+	for _, p := range trustedProxies {
+		if p.Contains(a) {
+			return true
+		}
 	}
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	return false
+}
+
+// parseIP reads an address, with or without a port, as a comparable value.
+func parseIP(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	a, err := netip.ParseAddr(s)
 	if err != nil {
+		ap, perr := netip.ParseAddrPort(s)
+		if perr != nil {
+			return netip.Addr{}, false
+		}
+		a = ap.Addr()
+	}
+	return a.Unmap().WithZone(""), true
+}
+
+// ClientIP is the visitor address, honoring proxy headers from trusted
+// proxies.
+func ClientIP(r *http.Request) string {
+	return clientIP(r)
+}
+
+// clientIP uses RemoteAddr unless it is a trusted proxy. Then it walks
+// X-Forwarded-For from the right and returns the first untrusted hop:
+// entries to its left were supplied by the client and can be forged. Only
+// valid IPs are taken from headers; at the first one that is not, the last
+// good hop (or the peer) is used.
+func clientIP(r *http.Request) string {
+	peer, ok := parseIP(r.RemoteAddr)
+	if !ok {
 		return r.RemoteAddr
 	}
-	return host
+	if !trusted(peer) {
+		return peer.String()
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, ok := parseIP(hops[i])
+			if !ok {
+				break
+			}
+			client = hop
+			if !trusted(hop) {
+				break
+			}
+		}
+		return client.String()
+	}
+	if xrip, ok := parseIP(r.Header.Get("X-Real-IP")); ok {
+		return xrip.String()
+	}
+	return peer.String()
 }
 
 func newReqID() string {
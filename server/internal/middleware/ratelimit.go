@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateRule limits requests whose method and path match. Path is a prefix;
+// an empty Method matches any method and Path "*" matches everything.
+type RateRule struct {
+	Method string
+	Path   string
+	Rate   float64 // tokens per second
+	Burst  float64
+	Off    bool
+}
+
+func (r RateRule) String() string {
+	return strings.TrimSpace(r.Method + " " + r.Path)
+}
+
+// ParseRateLimits reads comma-separated "[METHOD ]PATH=N/PERIOD[+BURST]"
+// entries, e.g. "POST /api/contact=5/10m, /healthz=off, *=600/m". BURST
+// defaults to N; PERIOD is a Go duration, or s, m, h for one unit.
+func ParseRateLimits(s string) ([]RateRule, error) {
+	var out []RateRule
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		route, spec, ok := strings.Cut(e, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: missing =", e)
+		}
+		var rule RateRule
+		fields := strings.Fields(route)
+		switch len(fields) {
+		case 1:
+			rule.Path = fields[0]
+		case 2:
+			rule.Method, rule.Path = strings.ToUpper(fields[0]), fields[1]
+		default:
+			return nil, fmt.Errorf("rate limit %q: bad route", e)
+		}
+
+		spec = strings.TrimSpace(spec)
+		if spec == "off" {
+			rule.Off = true
+			out = append(out, rule)
+			continue
+		}
+		spec, burst, hasBurst := strings.Cut(spec, "+")
+		n, per, ok := strings.Cut(spec, "/")
+		count, err := strconv.Atoi(n)
+		if !ok || err != nil || count <= 0 {
+			return nil, fmt.Errorf("rate limit %q: want N/PERIOD", e)
+		}
+		period, err := parsePeriod(per)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %q: %v", e, err)
+		}
+		rule.Rate = float64(count) / period.Seconds()
+		rule.Burst = float64(count)
+		if hasBurst {
+			b, err := strconv.Atoi(burst)
+			if err != nil || b <= 0 {
+				return nil, fmt.Errorf("rate limit %q: bad burst", e)
+			}
+			rule.Burst = float64(b)
+		}
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+func parsePeriod(s string) (time.Duration, error) {
+	switch s {
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("period must be positive")
+	}
+	return d, err
+}
+
+// match returns the most specific rule for r: longest path, then one with
+// a method over one without.
+func match(rules []RateRule, r *http.Request) (RateRule, bool) {
+	var best RateRule
+	found := false
+	for _, rule := range rules {
+		if rule.Method != "" && rule.Method != r.Method {
+			continue
+		}
+		if rule.Path != "*" && !strings.HasPrefix(r.URL.Path, rule.Path) {
+			continue
+		}
+		if !found || specificity(rule) > specificity(best) {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
+
+func specificity(r RateRule) int {
+	n := 0
+	if r.Path != "*" {
+		n = 2 * len(r.Path)
+	}
+	if r.Method != "" {
+		n++
+	}
+	return n
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Duration // time from empty to full; idle longer and it is fresh again
+}
+
+// take refills b up to burst and spends one token. When empty it returns
+// how long until the next token.
+func (b *bucket) take(rule RateRule, now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(rule.Burst, b.tokens+now.Sub(b.last).Seconds()*rule.Rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+}
+
+// limiter holds buckets keyed by rule and client (per-IP) or by rule alone
+// (global).
+type limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// maxBuckets caps memory. When a sweep does not free room, the least
+// recently used tenth is evicted; those clients start with a full bucket.
+const maxBuckets = 100_000
+
+func (l *limiter) allow(key string, rule RateRule, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.sweepLocked(now)
+		}
+		if len(l.buckets) >= maxBuckets {
+			l.evictOldestLocked(maxBuckets / 10)
+		}
+		b = &bucket{tokens: rule.Burst, last: now, full: time.Duration(rule.Burst / rule.Rate * float64(time.Second))}
+		l.buckets[key] = b
+	}
+	return b.take(rule, now)
+}
+
+// sweepLocked drops buckets idle long enough to have refilled completely;
+// a new bucket would be identical.
+func (l *limiter) sweepLocked(now time.Time) {
+	for k, b := range l.buckets {
+		if now.Sub(b.last) >= b.full {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// evictOldestLocked drops the n least recently used buckets.
+func (l *limiter) evictOldestLocked(n int) {
+	keys := make([]string, 0, len(l.buckets))
+	for k := range l.buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return l.buckets[keys[i]].last.Before(l.buckets[keys[j]].last) })
+	for _, k := range keys[:min(n, len(keys))] {
+		delete(l.buckets, k)
+	}
+}
+
+func (l *limiter) evictIdle(every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for now := range t.C {
+		l.mu.Lock()
+		l.sweepLocked(now)
+		l.mu.Unlock()
+	}
+}
+
+// WithRateLimit enforces token buckets per client IP (perIP) and per route
+// across all clients (global). Rejected requests get 429 with Retry-After.
+// Idle buckets are evicted every minute.
+func WithRateLimit(perIP, global []RateRule, next http.Handler) http.Handler {
+	l := &limiter{buckets: map[string]*bucket{}}
+	go l.evictIdle(time.Minute)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		now := time.Now()
+		if rule, ok := match(perIP, r); ok && !rule.Off {
+			if ok, wait := l.allow("ip|"+rule.String()+"|"+rateKey(clientIP(r)), rule, now); !ok {
+				tooMany(w, wait)
+				return
+			}
+		}
+		if rule, ok := match(global, r); ok && !rule.Off {
+			if ok, wait := l.allow("all|"+rule.String(), rule, now); !ok {
+				tooMany(w, wait)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateKey groups IPv6 clients by /64, the block a single subscriber
+// usually holds, so rotating addresses within it earns no fresh buckets.
+func rateKey(ip string) string {
+	a, err := netip.ParseAddr(ip)
+	if err != nil || !a.Is6() {
+		return ip
+	}
+	p, _ := a.Prefix(64)
+	return p.String()
+}
+
+func tooMany(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+	http.Error(w, "too many requests", http.StatusTooManyRequests)
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []RateRule
+	}{
+		{"", nil},
+		{"POST /api/contact=5/10m", []RateRule{{Method: "POST", Path: "/api/contact", Rate: 5.0 / 600, Burst: 5}}},
+		{"get /x=2/s+10", []RateRule{{Method: "GET", Path: "/x", Rate: 2, Burst: 10}}},
+		{" /healthz=off , *=600/m", []RateRule{{Path: "/healthz", Off: true}, {Path: "*", Rate: 10, Burst: 600}}},
+		{"/a=1/h", []RateRule{{Path: "/a", Rate: 1.0 / 3600, Burst: 1}}},
+	} {
+		got, err := ParseRateLimits(tc.in)
+		if err != nil {
+			t.Errorf("ParseRateLimits(%q): %v", tc.in, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("ParseRateLimits(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{
+		"/a", "/a=", "/a=5", "/a=0/m", "/a=-1/m", "/a=x/m", "/a=5/0s", "/a=5/-1m", "/a=5/fortnight",
+		"/a=5/m+0", "/a=5/m+x", "GET POST /a=5/m",
+	} {
+		if _, err := ParseRateLimits(bad); err == nil {
+			t.Errorf("ParseRateLimits(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules, err := ParseRateLimits("*=600/m, /api=100/m, POST /api=10/m, /api/contact=50/m, POST /api/contact=5/10m, /healthz=off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		method, path, want string
+	}{
+		{"POST", "/api/contact", "POST /api/contact"},
+		{"GET", "/api/contact", "/api/contact"},
+		{"POST", "/api/refresh", "POST /api"},
+		{"GET", "/api/cache", "/api"},
+		{"GET", "/healthz", "/healthz"},
+		{"GET", "/photos/a.jpg", "*"},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		rule, ok := match(rules, r)
+		if !ok || rule.String() != tc.want {
+			t.Errorf("%s %s matched %q, want %q", tc.method, tc.path, rule.String(), tc.want)
+		}
+	}
+
+	if _, ok := match([]RateRule{{Method: "POST", Path: "/api"}}, httptest.NewRequest("GET", "/api", nil)); ok {
+		t.Error("method-specific rule matched another method")
+	}
+}
+
+func TestBucketTake(t *testing.T) {
+	rule := RateRule{Rate: 1, Burst: 2} // one token per second, two at most
+	now := time.Now()
+	b := &bucket{tokens: rule.Burst, last: now}
+
+	for _, tc := range []struct {
+		at   time.Duration
+		ok   bool
+		wait time.Duration
+	}{
+		{0, true, 0},
+		{0, true, 0},
+		{0, false, time.Second},
+		{500 * time.Millisecond, false, 500 * time.Millisecond},
+		{time.Second, true, 0},
+		{10 * time.Second, true, 0}, // refilled, capped at Burst
+		{10 * time.Second, true, 0},
+		{10 * time.Second, false, time.Second},
+	} {
+		ok, wait := b.take(rule, now.Add(tc.at))
+		if ok != tc.ok || (wait-tc.wait).Abs() > time.Millisecond {
+			t.Errorf("take at +%v = %v, %v; want %v, %v", tc.at, ok, wait, tc.ok, tc.wait)
+		}
+	}
+}
+
+func TestLimiterEviction(t *testing.T) {
+	rule := RateRule{Rate: 1, Burst: 10} // full after 10 s idle
+	now := time.Now()
+	l := &limiter{buckets: map[string]*bucket{}}
+	l.allow("idle", rule, now)
+	l.allow("busy", rule, now.Add(9*time.Second))
+
+	l.sweepLocked(now.Add(10 * time.Second))
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("refilled bucket not swept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("partly drained bucket swept")
+	}
+
+	// Past the cap, with nothing idle, the oldest buckets go.
+	slow := RateRule{Rate: 1e-9, Burst: 1}
+	l = &limiter{buckets: map[string]*bucket{}}
+	for i := 0; i < maxBuckets; i++ {
+		l.allow(fmt.Sprint(i), slow, now.Add(time.Duration(i)))
+	}
+	l.allow("new", slow, now.Add(time.Hour))
+	if n := len(l.buckets); n > maxBuckets {
+		t.Fatalf("%d buckets, cap is %d", n, maxBuckets)
+	}
+	if _, ok := l.buckets["0"]; ok {
+		t.Error("oldest bucket kept")
+	}
+	if _, ok := l.buckets[fmt.Sprint(maxBuckets-1)]; !ok {
+		t.Error("newest bucket evicted")
+	}
+	if _, ok := l.buckets["new"]; !ok {
+		t.Error("new bucket not stored")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("127.0.0.1, 10.0.0.0/8, fd00::/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTrustedProxies(proxies)
+	t.Cleanup(func() { SetTrustedProxies(nil) })
+
+	for _, tc := range []struct {
+		name, remote string
+		xff          []string
+		xrip, want   string
+	}{
+		{"direct", "198.51.100.7:4000", nil, "", "198.51.100.7"},
+		{"direct ignores headers", "198.51.100.7:4000", []string{"203.0.113.1"}, "203.0.113.2", "198.51.100.7"},
+		{"proxied", "127.0.0.1:4000", []string{"203.0.113.1"}, "", "203.0.113.1"},
+		{"forged left hops", "127.0.0.1:4000", []string{"1.2.3.4, 203.0.113.1"}, "", "203.0.113.1"},
+		{"proxy chain", "127.0.0.1:4000", []string{"203.0.113.1, 10.0.0.2, 10.0.0.3"}, "", "203.0.113.1"},
+		{"all hops trusted", "127.0.0.1:4000", []string{"10.0.0.9, 10.0.0.2"}, "", "10.0.0.9"},
+		{"repeated header", "127.0.0.1:4000", []string{"1.2.3.4", "203.0.113.1"}, "", "203.0.113.1"},
+		{"hop with port", "127.0.0.1:4000", []string{"203.0.113.1:5555"}, "", "203.0.113.1"},
+		{"mapped IPv4", "127.0.0.1:4000", []string{"::ffff:203.0.113.1"}, "", "203.0.113.1"},
+		{"IPv6 peer", "[fd00::1]:4000", []string{"2001:db8::5"}, "", "2001:db8::5"},
+		{"garbage hop", "127.0.0.1:4000", []string{"evil"}, "", "127.0.0.1"},
+		{"garbage behind trusted hop", "127.0.0.1:4000", []string{"evil, 10.0.0.2"}, "", "10.0.0.2"},
+		{"real ip", "127.0.0.1:4000", nil, "203.0.113.9", "203.0.113.9"},
+		{"garbage real ip", "127.0.0.1:4000", nil, "anything", "127.0.0.1"},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.remote
+		for _, v := range tc.xff {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if tc.xrip != "" {
+			r.Header.Set("X-Real-IP", tc.xrip)
+		}
+		if got := clientIP(r); got != tc.want {
+			t.Errorf("%s: clientIP = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.1/x"} {
+		if _, err := ParseTrustedProxies(bad); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded", bad)
+		}
+	}
+	if p, err := ParseTrustedProxies("none"); err != nil || len(p) != 0 {
+		t.Errorf("ParseTrustedProxies(none) = %v, %v", p, err)
+	}
+}
+
+func TestRateKey(t *testing.T) {
+	for _, tc := range []struct{ ip, want string }{
+		{"203.0.113.7", "203.0.113.7"},
+		{"2001:db8:1:2:aaaa::1", "2001:db8:1:2::/64"},
+		{"2001:db8:1:2:bbbb::9", "2001:db8:1:2::/64"},
+		{"2001:db8:1:3::1", "2001:db8:1:3::/64"},
+		{"not-an-ip", "not-an-ip"},
+	} {
+		if got := rateKey(tc.ip); got != tc.want {
+			t.Errorf("rateKey(%q) = %q, want %q", tc.ip, got, tc.want)
+		}
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	perIP, _ := ParseRateLimits("POST /api/contact=2/h")
+	h := WithRateLimit(perIP, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	post := func(remote string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/contact", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	for i, remote := range []string{"[2001:db8::1]:1", "[2001:db8::2]:1"} {
+		if w := post(remote); w.Code != http.StatusOK {
+			t.Fatalf("request %d: %d", i, w.Code)
+		}
+	}
+	// A third address in the same /64 shares the bucket.
+	w := post("[2001:db8::3]:1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("same /64: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := post("[2001:db8:0:1::1]:1"); w.Code != http.StatusOK {
+		t.Errorf("other /64: %d", w.Code)
+	}
+}
@@ -0,0 +1,304 @@
+// Package og renders 1200x630 Open Graph share cards from cached previews:
+// a framed photo with title and EXIF line, or a collage for the album.
+// Cards are cached under DATA_DIR/og/ until cache.json changes.
+package og
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/webp"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/fsutil"
+	"github.com/ShinysArc/photography-portfolio/server/internal/site"
+)
+
+const (
+	Width  = 1200
+	Height = 630
+
+	pad       = 40
+	bandH     = 130 // caption band under the photo
+	collageN  = 6
+	jpegQual  = 85
+	titleSize = 44
+	metaSize  = 24
+)
+
+var (
+	bg     = color.RGBA{0x11, 0x11, 0x11, 0xff}
+	fg     = color.RGBA{0xf5, 0xf5, 0xf5, 0xff}
+	muted  = color.RGBA{0xa3, 0xa3, 0xa3, 0xff}
+	shadow = color.RGBA{0, 0, 0, 0xb0}
+
+	ErrNotFound = errors.New("og: not found")
+
+	renderMu sync.Mutex // one render at a time; cards are cheap to serve once cached
+)
+
+func dir(cfg config.Config) string {
+	return filepath.Join(cfg.DataDir, "og")
+}
+
+// PhotoCard returns the path of the card for one public photo, rendering
+// it if missing or older than cache.json.
+func PhotoCard(cfg config.Config, data cache.File, id string) (string, error) {
+	for _, it := range data.Items {
+		if it.ID == id && it.PreviewPath != "" {
+			return cached(cfg, "photo-"+id+".jpg", func() (image.Image, error) { return renderPhoto(cfg, it) })
+		}
+	}
+	return "", ErrNotFound
+}
+
+// AlbumCard returns the path of the album collage card.
+func AlbumCard(cfg config.Config, data cache.File) (string, error) {
+	return cached(cfg, "album.jpg", func() (image.Image, error) { return renderAlbum(cfg, data) })
+}
+
+// Prune removes cards for photos that are no longer public.
+func Prune(cfg config.Config, data cache.File) {
+	keep := map[string]bool{"album.jpg": true}
+	for _, it := range data.Items {
+		keep["photo-"+it.ID+".jpg"] = true
+	}
+	ents, err := os.ReadDir(dir(cfg))
+	if err != nil {
+		return
+	}
+	for _, e := range ents {
+		if !keep[e.Name()] {
+			_ = os.Remove(filepath.Join(dir(cfg), e.Name()))
+		}
+	}
+}
+
+func cached(cfg config.Config, name string, render func() (image.Image, error)) (string, error) {
+	p := filepath.Join(dir(cfg), name)
+	renderMu.Lock()
+	defer renderMu.Unlock()
+
+	if fresh(cfg, p) {
+		return p, nil
+	}
+	img, err := render()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir(cfg), 0o755); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQual}); err != nil {
+		return "", err
+	}
+	if err := fsutil.WriteAtomic(p, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+func fresh(cfg config.Config, p string) bool {
+	st, err := os.Stat(p)
+	if err != nil {
+		return false
+	}
+	src, err := os.Stat(filepath.Join(cfg.DataDir, "cache.json"))
+	return err == nil && !st.ModTime().Before(src.ModTime())
+}
+
+func loadPreview(cfg config.Config, it cache.Item) (image.Image, error) {
+	f, err := os.Open(filepath.Join(cfg.DataDir, it.PreviewPath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", it.PreviewPath, err)
+	}
+	return img, nil
+}
+
+func canvas() *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	return dst
+}
+
+func renderPhoto(cfg config.Config, it cache.Item) (image.Image, error) {
+	src, err := loadPreview(cfg, it)
+	if err != nil {
+		return nil, err
+	}
+	dst := canvas()
+
+	frame := image.Rect(pad, pad, Width-pad, Height-bandH)
+	xdraw.CatmullRom.Scale(dst, fit(src.Bounds(), frame), src, src.Bounds(), xdraw.Over, nil)
+
+	title, meta := faces()
+	y := Height - bandH + pad + titleSize - 8
+	text(dst, title, fg, pad, y, Width-2*pad, site.Title(it))
+	line := site.ExifLine(it)
+	if cfg.SiteTitle != "" {
+		line = strings.TrimPrefix(line+" — "+cfg.SiteTitle, " — ")
+	}
+	text(dst, meta, muted, pad, y+metaSize+14, Width-2*pad, line)
+	return dst, nil
+}
+
+func renderAlbum(cfg config.Config, data cache.File) (image.Image, error) {
+	picks := collagePicks(data)
+	dst := canvas()
+
+	cols, rows := 3, 2
+	switch {
+	case len(picks) < 4:
+		cols, rows = max(len(picks), 1), 1
+	case len(picks) < collageN:
+		cols, rows = 2, 2
+	}
+	const gap = 4
+	tw := (Width - gap*(cols-1)) / cols
+	th := (Height - gap*(rows-1)) / rows
+	for i, it := range picks {
+		if i >= cols*rows {
+			break
+		}
+		src, err := loadPreview(cfg, it)
+		if err != nil {
+			continue
+		}
+		x, y := (i%cols)*(tw+gap), (i/cols)*(th+gap)
+		cell := image.Rect(x, y, x+tw, y+th)
+		xdraw.ApproxBiLinear.Scale(dst, cell, src, cover(src.Bounds(), cell), xdraw.Src, nil)
+	}
+
+	// Caption band over the bottom of the collage.
+	band := image.Rect(0, Height-bandH, Width, Height)
+	draw.Draw(dst, band, image.NewUniform(shadow), image.Point{}, draw.Over)
+	title, meta := faces()
+	name := cfg.SiteTitle
+	if name == "" {
+		name = data.Album.Name
+	}
+	y := Height - bandH + pad + titleSize - 12
+	text(dst, title, fg, pad, y, Width-2*pad, name)
+	count := strconv.Itoa(len(data.Items)) + " photos"
+	if cfg.AuthorName != "" {
+		count = cfg.AuthorName + " · " + count
+	}
+	text(dst, meta, muted, pad, y+metaSize+14, Width-2*pad, count)
+	return dst, nil
+}
+
+// collagePicks prefers featured items, then the cache order.
+func collagePicks(data cache.File) []cache.Item {
+	byID := map[string]cache.Item{}
+	for _, it := range data.Items {
+		if it.PreviewPath != "" {
+			byID[it.ID] = it
+		}
+	}
+	var out []cache.Item
+	seen := map[string]bool{}
+	add := func(id string) {
+		if it, ok := byID[id]; ok && !seen[id] && len(out) < collageN {
+			seen[id] = true
+			out = append(out, it)
+		}
+	}
+	for _, id := range data.Featured {
+		add(id)
+	}
+	for _, it := range data.Items {
+		add(it.ID)
+	}
+	return out
+}
+
+// fit scales src into frame, preserving aspect ratio and centering.
+func fit(src, frame image.Rectangle) image.Rectangle {
+	sw, sh := src.Dx(), src.Dy()
+	fw, fh := frame.Dx(), frame.Dy()
+	w, h := fw, sh*fw/max(sw, 1)
+	if h > fh {
+		w, h = sw*fh/max(sh, 1), fh
+	}
+	x := frame.Min.X + (fw-w)/2
+	y := frame.Min.Y + (fh-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// cover returns the centered part of src with cell's aspect ratio.
+func cover(src, cell image.Rectangle) image.Rectangle {
+	sw, sh := src.Dx(), src.Dy()
+	w, h := sw, sw*cell.Dy()/max(cell.Dx(), 1)
+	if h > sh {
+		w, h = sh*cell.Dx()/max(cell.Dy(), 1), sh
+	}
+	x := src.Min.X + (sw-w)/2
+	y := src.Min.Y + (sh-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+var (
+	fontsOnce sync.Once
+	titleFace font.Face
+	metaFace  font.Face
+)
+
+// faces loads the bundled Go fonts.
+func faces() (font.Face, font.Face) {
+	fontsOnce.Do(func() {
+		titleFace = mustFace(gobold.TTF, titleSize)
+		metaFace = mustFace(goregular.TTF, metaSize)
+	})
+	return titleFace, metaFace
+}
+
+func mustFace(ttf []byte, size float64) font.Face {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		panic(err)
+	}
+	return face
+}
+
+// text draws s at baseline y, shortened with an ellipsis to fit maxW.
+func text(dst draw.Image, face font.Face, c color.Color, x, y, maxW int, s string) {
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face}
+	limit := fixed.I(maxW)
+	if d.MeasureString(s) > limit {
+		r := []rune(s)
+		for len(r) > 0 && d.MeasureString(string(r)+"…") > limit {
+			r = r[:len(r)-1]
+		}
+		s = strings.TrimSpace(string(r)) + "…"
+	}
+	d.Dot = fixed.P(x, y)
+	d.DrawString(s)
+}
@@ -0,0 +1,81 @@
+// Package site builds public URLs for pages and images served by the web app.
+package site
+
+import (
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+// Home is the gallery page.
+func Home(cfg config.Config) string {
+	return cfg.PublicURL + "/"
+}
+
+// PhotoURL opens a single photo in the gallery.
+func PhotoURL(cfg config.Config, id string) string {
+	return cfg.PublicURL + "/?photo=" + url.QueryEscape(id)
+}
+
+// ImageURL is the cached preview as served from DATA_DIR under /photos.
+func ImageURL(cfg config.Config, it cache.Item) string {
+	if it.PreviewPath == "" {
+		return ""
+	}
+	return cfg.PublicURL + "/photos/" + it.PreviewPath
+}
+
+// ImageType guesses the preview MIME type from its extension.
+func ImageType(it cache.Item) string {
+	if t := mime.TypeByExtension(path.Ext(it.PreviewPath)); t != "" {
+		return t
+	}
+	return "image/jpeg"
+}
+
+// Title is the first description line, else the file name without extension.
+func Title(it cache.Item) string {
+	if it.Description != nil {
+		first, _, _ := strings.Cut(*it.Description, "\n")
+		if first = strings.TrimSpace(first); first != "" {
+			return first
+		}
+	}
+	if it.OriginalFileName != nil {
+		return strings.TrimSuffix(*it.OriginalFileName, path.Ext(*it.OriginalFileName))
+	}
+	return it.ID
+}
+
+// ExifLine summarizes the shot: "Canon EOS R6 Mark II · 35 mm · f/2.8 · 1/250 s · ISO 400".
+func ExifLine(it cache.Item) string {
+	d := it.Display
+	if d == nil {
+		return ""
+	}
+	var parts []string
+	for _, v := range []string{d.Camera, d.FocalLength, d.Aperture, d.Exposure, d.ISO} {
+		if v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// TagNames lists tag labels.
+func TagNames(it cache.Item) []string {
+	out := make([]string, 0, len(it.Tags))
+	for _, t := range it.Tags {
+		switch {
+		case t.Name != nil && *t.Name != "":
+			out = append(out, *t.Name)
+		case t.Value != nil && *t.Value != "":
+			out = append(out, *t.Value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+// Package sitemap writes DATA_DIR/sitemap.xml with page URLs and Google
+// image-sitemap extensions, rebuilt after every cache publish.
+package sitemap
+
+import (
+	"encoding/xml"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+	"github.com/ShinysArc/photography-portfolio/server/internal/fsutil"
+	"github.com/ShinysArc/photography-portfolio/server/internal/site"
+)
+
+const ContentType = "application/xml; charset=utf-8"
+
+// maxImages is Google's limit of image entries per URL.
+const maxImages = 1000
+
+func Path(cfg config.Config) string {
+	return filepath.Join(cfg.DataDir, "sitemap.xml")
+}
+
+type urlset struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	ImageNS string   `xml:"xmlns:image,attr"`
+	URLs    []url    `xml:"url"`
+}
+
+type url struct {
+	Loc     string  `xml:"loc"`
+	LastMod string  `xml:"lastmod,omitempty"`
+	Images  []image `xml:"image:image"`
+}
+
+type image struct {
+	Loc         string `xml:"image:loc"`
+	Title       string `xml:"image:title,omitempty"`
+	Caption     string `xml:"image:caption,omitempty"`
+	GeoLocation string `xml:"image:geo_location,omitempty"`
+}
+
+// Rebuild regenerates the sitemap; the file is only rewritten on change.
+func Rebuild(cfg config.Config, data cache.File) {
+	b, err := Render(cfg, data)
+	if err != nil {
+		log.Printf("sitemap: %v", err)
+		return
+	}
+	if err := fsutil.WriteIfChanged(Path(cfg), b, 0o644); err != nil {
+		log.Printf("sitemap: %v", err)
+	}
+}
+
+func Render(cfg config.Config, data cache.File) ([]byte, error) {
+	doc := urlset{
+		NS:      "http://www.sitemaps.org/schemas/sitemap/0.9",
+		ImageNS: "http://www.google.com/schemas/sitemap-image/1.1",
+	}
+
+	// The album page lists every photo.
+	album := url{Loc: site.Home(cfg)}
+	var newest time.Time
+	photos := make([]url, 0, len(data.Items))
+	for _, it := range data.Items {
+		if it.PreviewPath == "" {
+			continue
+		}
+		img := imageOf(cfg, it)
+		if len(album.Images) < maxImages {
+			album.Images = append(album.Images, img)
+		}
+
+		u := url{Loc: site.PhotoURL(cfg, it.ID), Images: []image{img}}
+		if t, ok := published(it); ok {
+			u.LastMod = t.Format("2006-01-02")
+			if t.After(newest) {
+				newest = t
+			}
+		}
+		photos = append(photos, u)
+	}
+	if !newest.IsZero() {
+		album.LastMod = newest.Format("2006-01-02")
+	}
+	doc.URLs = append(doc.URLs, album)
+	doc.URLs = append(doc.URLs, photos...)
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func imageOf(cfg config.Config, it cache.Item) image {
+	img := image{Loc: site.ImageURL(cfg, it), Title: site.Title(it)}
+	if it.Description != nil {
+		img.Caption = *it.Description
+	}
+	if loc := it.Location; loc != nil {
+		var parts []string
+		for _, p := range []*string{loc.City, loc.State, loc.Country} {
+			if p != nil && *p != "" {
+				parts = append(parts, *p)
+			}
+		}
+		img.GeoLocation = strings.Join(parts, ", ")
+	}
+	return img
+}
+
+func published(it cache.Item) (time.Time, bool) {
+	if it.Published == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, *it.Published)
+	return t, err == nil
+}
@@ -0,0 +1,165 @@
+// Package stats computes shooting statistics from the public cache.
+package stats
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+)
+
+const topTags = 15
+
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+type Bucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+type TagCount struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type Stats struct {
+	Total       int            `json:"total"`
+	ByYear      []Count        `json:"byYear"`  // "2024"
+	ByMonth     []Count        `json:"byMonth"` // "2024-05"
+	FocalLength []Bucket       `json:"focalLength"`
+	Aperture    []Bucket       `json:"aperture"`
+	ISO         []Bucket       `json:"iso"`
+	HourOfDay   [24]int        `json:"hourOfDay"` // local time of capture
+	Orientation map[string]int `json:"orientation"`
+	TopTags     []TagCount     `json:"topTags"`
+}
+
+// histogram buckets are upper bounds (inclusive); the last one catches the rest.
+type histogram struct {
+	labels []string
+	bounds []float64
+}
+
+// Focal lengths are 35mm-equivalent when the crop factor is known.
+var (
+	focalHist = histogram{
+		labels: []string{"< 24 mm", "24–34 mm", "35–49 mm", "50–84 mm", "85–134 mm", "135–199 mm", "200 mm +"},
+		bounds: []float64{23.5, 34.5, 49.5, 84.5, 134.5, 199.5},
+	}
+	apertureHist = histogram{
+		labels: []string{"≤ f/1.4", "f/1.8–2", "f/2.2–2.8", "f/3.2–4", "f/4.5–5.6", "f/6.3–8", "f/9–11", "> f/11"},
+		bounds: []float64{1.45, 2.05, 2.85, 4.05, 5.65, 8.05, 11.05},
+	}
+	isoHist = histogram{
+		labels: []string{"≤ 100", "125–200", "250–400", "500–800", "1000–1600", "2000–3200", "4000–6400", "> 6400"},
+		bounds: []float64{100, 200, 400, 800, 1600, 3200, 6400},
+	}
+)
+
+func (h histogram) empty() []Bucket {
+	out := make([]Bucket, len(h.labels))
+	for i, l := range h.labels {
+		out[i].Label = l
+	}
+	return out
+}
+
+func (h histogram) add(b []Bucket, v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v)
+	b[i].Count++
+}
+
+func Compute(data cache.File) Stats {
+	s := Stats{
+		Total:       len(data.Items),
+		FocalLength: focalHist.empty(),
+		Aperture:    apertureHist.empty(),
+		ISO:         isoHist.empty(),
+		Orientation: map[string]int{"landscape": 0, "portrait": 0, "square": 0},
+	}
+	years := map[string]int{}
+	months := map[string]int{}
+	tags := map[string]*TagCount{}
+
+	for _, it := range data.Items {
+		ex := it.Exif
+		if t, ok := it.LocalTakenAt(); ok {
+			years[t.Format("2006")]++
+			months[t.Format("2006-01")]++
+			s.HourOfDay[t.Hour()]++
+		}
+
+		focal := ex.FocalLength
+		if it.Display != nil && it.Display.FocalLength35mm != nil {
+			focal = it.Display.FocalLength35mm
+		}
+		if focal != nil && *focal > 0 {
+			focalHist.add(s.FocalLength, *focal)
+		}
+		if ex.FNumber != nil && *ex.FNumber > 0 {
+			apertureHist.add(s.Aperture, *ex.FNumber)
+		}
+		if ex.ISO != nil && *ex.ISO > 0 {
+			isoHist.add(s.ISO, float64(*ex.ISO))
+		}
+
+		if w, h, ok := it.Size(); ok {
+			switch {
+			case w > h:
+				s.Orientation["landscape"]++
+			case h > w:
+				s.Orientation["portrait"]++
+			case w > 0:
+				s.Orientation["square"]++
+			}
+		}
+
+		for _, t := range it.Tags {
+			tc := tags[t.ID]
+			if tc == nil {
+				tc = &TagCount{ID: t.ID, Name: tagName(t.Name, t.Value)}
+				tags[t.ID] = tc
+			}
+			tc.Count++
+		}
+	}
+
+	s.ByYear = sortedCounts(years)
+	s.ByMonth = sortedCounts(months)
+
+	s.TopTags = make([]TagCount, 0, len(tags))
+	for _, tc := range tags {
+		s.TopTags = append(s.TopTags, *tc)
+	}
+	slices.SortFunc(s.TopTags, func(a, b TagCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Name, b.Name))
+	})
+	if len(s.TopTags) > topTags {
+		s.TopTags = s.TopTags[:topTags]
+	}
+	return s
+}
+
+func sortedCounts(m map[string]int) []Count {
+	out := make([]Count, 0, len(m))
+	for k, v := range m {
+		out = append(out, Count{Key: k, Count: v})
+	}
+	slices.SortFunc(out, func(a, b Count) int { return strings.Compare(a.Key, b.Key) })
+	return out
+}
+
+func tagName(name, value *string) string {
+	if name != nil && *name != "" {
+		return *name
+	}
+	if value != nil {
+		return *value
+	}
+	return ""
+}
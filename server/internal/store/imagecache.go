@@ -6,8 +6,7 @@ import (
 	"fmt"
 	"image"
 	_ "image/jpeg"
-    _ "image/png"
-    _ "golang.org/x/image/webp"
+	_ "image/png"
 	"io"
 	"net/http"
 	"os"
@@ -16,6 +15,7 @@ import (
 	"sync"
 
 	thumbhash "github.com/galdor/go-thumbhash"
+	_ "golang.org/x/image/webp"
 
 	"github.com/ShinysArc/photography-portfolio/server/internal/config"
 	"github.com/ShinysArc/photography-portfolio/server/internal/immich"
@@ -25,10 +25,18 @@ import (
 Directory layout:
 
 DATA_DIR/
-  img/
-    preview/   <id>.<ext>
+  preview/     <id>.<ext>   (Immich "preview", ~1440px)
+  thumbnail/   <id>.<ext>   (Immich "thumbnail", ~250px)
 */
 
+// Renditions fetched for every asset, largest first.
+const (
+	Preview   = "preview"
+	Thumbnail = "thumbnail"
+)
+
+var renditions = []string{Preview, Thumbnail}
+
 var knownExts = []string{".jpg", ".jpeg", ".webp", ".png", ".avif"}
 
 var mimeFromExt = map[string]string{
@@ -62,21 +70,28 @@ func ComputeThumbHashFromFile(path string) (string, error) {
 	return base64.StdEncoding.EncodeToString(h), nil
 }
 
-func dirFor(cfg config.Config) string {
-	return filepath.Join(cfg.DataDir, "preview")
+func dirFor(cfg config.Config, kind string) string {
+	return filepath.Join(cfg.DataDir, kind)
 }
 
 func EnsureDirs(cfg config.Config) error {
-	dir := dirFor(cfg)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return err
+	for _, kind := range renditions {
+		dir := dirFor(cfg, kind)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		_ = os.Chmod(dir, 0o755)
 	}
-	_ = os.Chmod(dir, 0o755)
 	return nil
 }
 
+// FindCached looks up the preview rendition of id.
 func FindCached(cfg config.Config, id string) (string, string, bool) {
-	base := dirFor(cfg)
+	return findRendition(cfg, Preview, id)
+}
+
+func findRendition(cfg config.Config, kind, id string) (string, string, bool) {
+	base := dirFor(cfg, kind)
 	for _, ext := range knownExts {
 		p := filepath.Join(base, id+ext)
 		if st, err := os.Stat(p); err == nil && !st.IsDir() {
@@ -86,12 +101,12 @@ func FindCached(cfg config.Config, id string) (string, string, bool) {
 	return "", "", false
 }
 
-func savePreviewFile(cfg config.Config, id, contentType string, r io.Reader) (string, error) {
+func saveFile(cfg config.Config, kind, id, contentType string, r io.Reader) (string, error) {
 	ext := extFromMime[strings.ToLower(strings.TrimSpace(contentType))]
 	if ext == "" {
 		ext = ".jpg"
 	}
-	dir := dirFor(cfg)
+	dir := dirFor(cfg, kind)
 
 	tmp := filepath.Join(dir, id+".tmp")
 	out, err := os.Create(tmp)
@@ -118,13 +133,17 @@ func savePreviewFile(cfg config.Config, id, contentType string, r io.Reader) (st
 	return final, nil
 }
 
-// DownloadAndCache fetches images from Immich and writes it to disk.
+// DownloadAndCache fetches the preview of id from Immich and writes it to disk.
 func DownloadAndCache(ctx context.Context, cfg config.Config, id string) (string, string, error) {
-	if p, ct, ok := FindCached(cfg, id); ok {
+	return downloadRendition(ctx, cfg, Preview, id)
+}
+
+func downloadRendition(ctx context.Context, cfg config.Config, kind, id string) (string, string, error) {
+	if p, ct, ok := findRendition(cfg, kind, id); ok {
 		return p, ct, nil
 	}
 
-	url := immich.ImageURL(cfg, id, "preview")
+	url := immich.ImageURL(cfg, id, kind)
 	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	req.Header.Set("x-api-key", cfg.ImmichAPIKey)
 
@@ -140,7 +159,7 @@ func DownloadAndCache(ctx context.Context, cfg config.Config, id string) (string
 	}
 
 	ct := res.Header.Get("Content-Type")
-	path, err := savePreviewFile(cfg, id, ct, res.Body)
+	path, err := saveFile(cfg, kind, id, ct, res.Body)
 	if err != nil {
 		return "", "", err
 	}
@@ -163,13 +182,15 @@ func Prefetch(ctx context.Context, cfg config.Config, ids []string) error {
 		unique = append(unique, id)
 	}
 
-	type job struct{ id string }
-	jobs := make([]job, 0, len(unique))
+	type job struct{ kind, id string }
+	jobs := make([]job, 0, len(unique)*len(renditions))
 	for _, id := range unique {
-		if _, _, ok := FindCached(cfg, id); ok {
-			continue
+		for _, kind := range renditions {
+			if _, _, ok := findRendition(cfg, kind, id); ok {
+				continue
+			}
+			jobs = append(jobs, job{kind: kind, id: id})
 		}
-		jobs = append(jobs, job{id: id})
 	}
 	if len(jobs) == 0 {
 		return nil
@@ -209,7 +230,7 @@ func Prefetch(ctx context.Context, cfg config.Config, ids []string) error {
 			next++
 			mu.Unlock()
 
-			if _, _, err := DownloadAndCache(ctx, cfg, j.id); err != nil {
+			if _, _, err := downloadRendition(ctx, cfg, j.kind, j.id); err != nil {
 				mu.Lock()
 				select {
 				case errs <- err:
@@ -235,8 +256,15 @@ func Prefetch(ctx context.Context, cfg config.Config, ids []string) error {
 
 // Prune removes cached files for IDs that are no longer in the album.
 func Prune(cfg config.Config, keepIDs map[string]struct{}) error {
-	dir := dirFor(cfg)
+	for _, kind := range renditions {
+		if err := pruneDir(dirFor(cfg, kind), keepIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func pruneDir(dir string, keepIDs map[string]struct{}) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"image"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,34 +10,77 @@ import (
 )
 
 type Paths struct {
-	Preview string // e.g. "preview/<id>.jpg"
+	Preview   string // e.g. "preview/<id>.jpg"
+	Thumbnail string // e.g. "thumbnail/<id>.webp"
 }
 
-// BuildPathIndex scans DATA_DIR/preview and returns id -> Paths.
+// Rendition is one cached size of an asset.
+type Rendition struct {
+	Kind   string
+	Path   string // relative to DATA_DIR
+	Width  int
+	Height int
+}
+
+// BuildPathIndex scans the rendition directories and returns id -> Paths.
 func BuildPathIndex(cfg config.Config) (map[string]Paths, error) {
 	root := cfg.DataDir
 	index := map[string]Paths{}
 
-	dir := filepath.Join(root, "preview")
-	_ = os.MkdirAll(dir, 0o755)
+	for _, kind := range renditions {
+		dir := filepath.Join(root, kind)
+		_ = os.MkdirAll(dir, 0o755)
 
-	ents, err := os.ReadDir(dir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return index, nil
+		ents, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, e := range ents {
+			if e.IsDir() {
+				continue
+			}
+			name := e.Name() // "<id>.<ext>"
+			if dot := strings.LastIndexByte(name, '.'); dot > 0 {
+				id := name[:dot]
+				p := index[id]
+				rel := filepath.ToSlash(filepath.Join(kind, name))
+				switch kind {
+				case Preview:
+					p.Preview = rel
+				case Thumbnail:
+					p.Thumbnail = rel
+				}
+				index[id] = p
+			}
 		}
-		return nil, err
 	}
+	return index, nil
+}
 
-	for _, e := range ents {
-		if e.IsDir() {
+// Renditions returns the cached sizes of id with their pixel dimensions,
+// largest first.
+func Renditions(cfg config.Config, id string) []Rendition {
+	var out []Rendition
+	for _, kind := range renditions {
+		p, _, ok := findRendition(cfg, kind, id)
+		if !ok {
 			continue
 		}
-		name := e.Name() // "<id>.<ext>"
-		if dot := strings.LastIndexByte(name, '.'); dot > 0 {
-			id := name[:dot]
-			index[id] = Paths{Preview: filepath.ToSlash(filepath.Join("preview", name))}
+		f, err := os.Open(p)
+		if err != nil {
+			continue
 		}
+		c, _, err := image.DecodeConfig(f)
+		_ = f.Close()
+		if err != nil {
+			continue
+		}
+		rel, _ := filepath.Rel(cfg.DataDir, p)
+		out = append(out, Rendition{Kind: kind, Path: filepath.ToSlash(rel), Width: c.Width, Height: c.Height})
 	}
-	return index, nil
+	return out
 }
@@ -0,0 +1,202 @@
+// Package stories serves curated photo sequences interleaved with Markdown
+// text, defined in CONTENT_DIR/stories.json.
+package stories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+	"github.com/ShinysArc/photography-portfolio/server/internal/config"
+)
+
+/*
+stories.json:
+
+	[
+	  {
+	    "slug": "lisbon-winter",
+	    "title": "Lisbon in winter",
+	    "date": "2025-01-12",
+	    "summary": "Three days of low sun.",
+	    "cover": "<asset-id>",
+	    "blocks": [
+	      { "text": "## Day one\nWe landed at dawn..." },
+	      { "photo": "<asset-id>", "caption": "Alfama rooftops" }
+	    ]
+	  }
+	]
+*/
+
+var slugRe = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+type Block struct {
+	Text    string `json:"text,omitempty"` // Markdown, rendered by the client
+	Photo   string `json:"photo,omitempty"`
+	Caption string `json:"caption,omitempty"`
+}
+
+type Story struct {
+	Slug    string  `json:"slug"`
+	Title   string  `json:"title"`
+	Date    string  `json:"date,omitempty"`
+	Summary string  `json:"summary,omitempty"`
+	Cover   string  `json:"cover,omitempty"`
+	Draft   bool    `json:"draft,omitempty"`
+	Blocks  []Block `json:"blocks"`
+}
+
+// ResolvedBlock is a block with its photo looked up in the cache.
+type ResolvedBlock struct {
+	Type    string      `json:"type"` // "text" or "photo"
+	Text    string      `json:"text,omitempty"`
+	Item    *cache.Item `json:"item,omitempty"`
+	Caption string      `json:"caption,omitempty"`
+}
+
+type Summary struct {
+	Slug       string      `json:"slug"`
+	Title      string      `json:"title"`
+	Date       string      `json:"date,omitempty"`
+	Summary    string      `json:"summary,omitempty"`
+	Cover      *cache.Item `json:"cover,omitempty"`
+	PhotoCount int         `json:"photoCount"`
+}
+
+type Resolved struct {
+	Summary
+	Blocks []ResolvedBlock `json:"blocks"`
+}
+
+var ErrNotFound = errors.New("story not found")
+
+func path(cfg config.Config) string {
+	return filepath.Join(cfg.ContentDir, "stories.json")
+}
+
+// Load reads the stories file; a missing file means no stories.
+func Load(cfg config.Config) ([]Story, error) {
+	b, err := os.ReadFile(path(cfg))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Story
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("%s: %w", path(cfg), err)
+	}
+	return out, nil
+}
+
+// Check validates stories against the public cache and returns
+// human-readable warnings for refresh output.
+func Check(cfg config.Config, data cache.File) []string {
+	list, err := Load(cfg)
+	if err != nil {
+		return []string{"stories: " + err.Error()}
+	}
+	ids := index(data)
+	var warns []string
+	seen := map[string]bool{}
+	for i, s := range list {
+		name := s.Slug
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		switch {
+		case !slugRe.MatchString(s.Slug):
+			warns = append(warns, fmt.Sprintf("story %s: invalid slug", name))
+		case seen[s.Slug]:
+			warns = append(warns, fmt.Sprintf("story %s: duplicate slug", name))
+		}
+		seen[s.Slug] = true
+		if s.Cover != "" && ids[s.Cover] == nil {
+			warns = append(warns, fmt.Sprintf("story %s: cover %s not in cache", name, s.Cover))
+		}
+		for j, b := range s.Blocks {
+			switch {
+			case b.Photo != "" && b.Text != "":
+				warns = append(warns, fmt.Sprintf("story %s: block %d has both text and photo", name, j))
+			case b.Photo != "" && ids[b.Photo] == nil:
+				warns = append(warns, fmt.Sprintf("story %s: block %d photo %s not in cache", name, j, b.Photo))
+			case b.Photo == "" && b.Text == "":
+				warns = append(warns, fmt.Sprintf("story %s: block %d is empty", name, j))
+			}
+		}
+	}
+	return warns
+}
+
+// List returns published stories in file order.
+func List(cfg config.Config, data cache.File) ([]Summary, error) {
+	list, err := Load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ids := index(data)
+	out := make([]Summary, 0, len(list))
+	for _, s := range list {
+		if s.Draft {
+			continue
+		}
+		out = append(out, resolve(s, ids).Summary)
+	}
+	return out, nil
+}
+
+// Get resolves one published story. Photos missing from the cache are
+// skipped so scheduled or removed assets never leak.
+func Get(cfg config.Config, data cache.File, slug string) (Resolved, error) {
+	list, err := Load(cfg)
+	if err != nil {
+		return Resolved{}, err
+	}
+	for _, s := range list {
+		if s.Slug == slug && !s.Draft {
+			return resolve(s, index(data)), nil
+		}
+	}
+	return Resolved{}, ErrNotFound
+}
+
+func resolve(s Story, ids map[string]*cache.Item) Resolved {
+	r := Resolved{
+		Summary: Summary{Slug: s.Slug, Title: s.Title, Date: s.Date, Summary: s.Summary},
+		Blocks:  make([]ResolvedBlock, 0, len(s.Blocks)),
+	}
+	for _, b := range s.Blocks {
+		switch {
+		case b.Photo != "":
+			it := ids[b.Photo]
+			if it == nil {
+				continue
+			}
+			r.Blocks = append(r.Blocks, ResolvedBlock{Type: "photo", Item: it, Caption: b.Caption})
+			r.PhotoCount++
+			if r.Cover == nil {
+				r.Cover = it
+			}
+		case b.Text != "":
+			r.Blocks = append(r.Blocks, ResolvedBlock{Type: "text", Text: b.Text})
+		}
+	}
+	if it := ids[s.Cover]; it != nil {
+		r.Cover = it
+	}
+	return r
+}
+
+func index(data cache.File) map[string]*cache.Item {
+	m := make(map[string]*cache.Item, len(data.Items))
+	for i := range data.Items {
+		m[data.Items[i].ID] = &data.Items[i]
+	}
+	return m
+}
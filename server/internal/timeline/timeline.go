@@ -0,0 +1,143 @@
+// Package timeline groups public photos by local capture date.
+package timeline
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/ShinysArc/photography-portfolio/server/internal/cache"
+)
+
+type Cover struct {
+	ID          string `json:"id"`
+	PreviewPath string `json:"previewPath,omitempty"`
+	ThumbHash   string `json:"thumbHash,omitempty"`
+
+	score int
+}
+
+type Day struct {
+	Day   int    `json:"day"`
+	Key   string `json:"key"` // "2024-05-04"
+	Count int    `json:"count"`
+	Cover Cover  `json:"cover"`
+}
+
+type Month struct {
+	Month int    `json:"month"`
+	Key   string `json:"key"` // "2024-05"
+	Count int    `json:"count"`
+	Cover Cover  `json:"cover"`
+	Days  []Day  `json:"days"`
+}
+
+type Year struct {
+	Year   int     `json:"year"`
+	Key    string  `json:"key"` // "2024"
+	Count  int     `json:"count"`
+	Cover  Cover   `json:"cover"`
+	Months []Month `json:"months"`
+}
+
+type Timeline struct {
+	Years   []Year `json:"years"` // newest first
+	Undated int    `json:"undated"`
+}
+
+type dated struct {
+	item cache.Item
+	at   time.Time
+}
+
+// byWallClock sorts newest first on local wall-clock time, so photos from
+// different zones still group under contiguous dates.
+func byWallClock(a, b dated) int {
+	return wall(b.at).Compare(wall(a.at))
+}
+
+func wall(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// Build groups items by year, month and day, newest first.
+func Build(data cache.File) Timeline {
+	var tl Timeline
+	items := make([]dated, 0, len(data.Items))
+	for _, it := range data.Items {
+		at, ok := it.LocalTakenAt()
+		if !ok {
+			tl.Undated++
+			continue
+		}
+		items = append(items, dated{it, at})
+	}
+	slices.SortStableFunc(items, byWallClock)
+
+	tl.Years = []Year{}
+	for _, d := range items {
+		y, m, day := d.at.Date()
+		if n := len(tl.Years); n == 0 || tl.Years[n-1].Year != y {
+			tl.Years = append(tl.Years, Year{Year: y, Key: d.at.Format("2006")})
+		}
+		yr := &tl.Years[len(tl.Years)-1]
+		if n := len(yr.Months); n == 0 || yr.Months[n-1].Month != int(m) {
+			yr.Months = append(yr.Months, Month{Month: int(m), Key: d.at.Format("2006-01")})
+		}
+		mo := &yr.Months[len(yr.Months)-1]
+		if n := len(mo.Days); n == 0 || mo.Days[n-1].Day != day {
+			mo.Days = append(mo.Days, Day{Day: day, Key: d.at.Format("2006-01-02")})
+		}
+		dy := &mo.Days[len(mo.Days)-1]
+
+		yr.Count++
+		mo.Count++
+		dy.Count++
+		pickCover(&yr.Cover, d.item)
+		pickCover(&mo.Cover, d.item)
+		pickCover(&dy.Cover, d.item)
+	}
+	return tl
+}
+
+// pickCover keeps the best candidate seen so far: favorites first, then
+// rating; ties keep the newest since items arrive newest first.
+func pickCover(c *Cover, it cache.Item) {
+	if s := it.Score(); c.ID == "" || s > c.score {
+		*c = Cover{ID: it.ID, PreviewPath: it.PreviewPath, ThumbHash: it.ThumbHash, score: s}
+	}
+}
+
+// Period returns the time layout matching "2024", "2024-05" or "2024-05-04".
+func Period(s string) (string, error) {
+	for _, l := range []string{"2006", "2006-01", "2006-01-02"} {
+		if len(s) == len(l) {
+			if _, err := time.Parse(l, s); err == nil {
+				return l, nil
+			}
+		}
+	}
+	return "", errors.New("period must be YYYY, YYYY-MM or YYYY-MM-DD")
+}
+
+// Items lists the items captured in period, newest first.
+func Items(data cache.File, period string) ([]cache.Item, error) {
+	layout, err := Period(period)
+	if err != nil {
+		return nil, err
+	}
+	var out []dated
+	for _, it := range data.Items {
+		if at, ok := it.LocalTakenAt(); ok && at.Format(layout) == period {
+			out = append(out, dated{it, at})
+		}
+	}
+	slices.SortStableFunc(out, byWallClock)
+
+	items := make([]cache.Item, len(out))
+	for i, d := range out {
+		items[i] = d.item
+	}
+	return items, nil
+}
@@ -3,8 +3,13 @@
 import clsx from 'clsx';
 import { useEffect, useMemo, useState } from 'react';
 
+import CaptchaWidget, { isWidgetProvider } from '@/components/CaptchaWidget';
+import { captchaToken } from '@/lib/pow';
+
 export default function ContactPage() {
   const [startedAt, setStartedAt] = useState<number | null>(null);
+  // server-signed form time; startedAt is only a fallback, the visitor's clock may be off
+  const [stamp, setStamp] = useState<string | null>(null);
   const [sending, setSending] = useState(false);
   const [ok, setOk] = useState<boolean | null>(null);
   const [error, setError] = useState<string | null>(null);
@@ -14,12 +19,52 @@ export default function ContactPage() {
   const [subject, setSubject] = useState('');
   const [message, setMessage] = useState('');
   const [hp, setHp] = useState('');
+  // inquiries about one photo: /contact?photo=<id>&inquiry=print|license|other
+  const [assetId, setAssetId] = useState('');
+  const [inquiry, setInquiry] = useState('other');
+  const [thumb, setThumb] = useState<string | null>(null);
+  const [files, setFiles] = useState<File[]>([]);
+  // Turnstile/hCaptcha: the widget supplies the token; pow is solved on submit
+  const [widget, setWidget] = useState<{ provider: string; siteKey: string } | null>(null);
+  const [widgetToken, setWidgetToken] = useState<string | undefined>();
+  const [widgetReset, setWidgetReset] = useState(0);
 
-  useEffect(() => setStartedAt(Date.now()), []);
+  useEffect(() => {
+    setStartedAt(Date.now());
+    fetch('/api/contact/stamp', { cache: 'no-store' })
+      .then((res) => (res.ok ? res.json() : null))
+      .then((data) => data?.stamp && setStamp(data.stamp))
+      .catch(() => {});
+    fetch('/api/captcha', { cache: 'no-store' })
+      .then((res) => (res.ok ? res.json() : null))
+      .then((cfg) => {
+        if (isWidgetProvider(cfg?.provider) && cfg.siteKey) {
+          setWidget({ provider: cfg.provider, siteKey: cfg.siteKey });
+        }
+      })
+      .catch(() => {});
+    const params = new URLSearchParams(window.location.search);
+    const photo = params.get('photo');
+    if (photo) {
+      setAssetId(photo);
+      const kind = params.get('inquiry');
+      if (kind === 'print' || kind === 'license') setInquiry(kind);
+      fetch('/photos/cache.json')
+        .then((res) => (res.ok ? res.json() : null))
+        .then((data) => {
+          const it = data?.items?.find((i: { id: string }) => i.id === photo);
+          const path = it?.thumbnailPath || it?.previewPath;
+          if (path) setThumb(`/photos/${path}`);
+        })
+        .catch(() => {});
+    }
+  }, []);
 
   const canSend = useMemo(() => {
-    return !sending && name.trim() && email.trim() && message.trim();
-  }, [sending, name, email, message]);
+    return (
+      !sending && name.trim() && email.trim() && message.trim() && (!widget || !!widgetToken)
+    );
+  }, [sending, name, email, message, widget, widgetToken]);
 
   async function onSubmit(e: React.FormEvent) {
     e.preventDefault();
@@ -30,30 +75,46 @@ export default function ContactPage() {
     setError(null);
 
     try {
-      const res = await fetch('/api/contact', {
-        method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({
-          name,
-          email,
-          subject,
-          message,
-          hp,
-          startedAt,
-        }),
-      });
+      const captcha = widget ? widgetToken : await captchaToken();
+      const fields = {
+        name,
+        email,
+        subject,
+        message,
+        hp,
+        startedAt,
+        stamp,
+        captcha,
+        ...(assetId ? { assetId, inquiry } : {}),
+      };
+      let body: BodyInit;
+      const headers: Record<string, string> = {};
+      if (files.length) {
+        // attachments go as multipart; the browser sets the boundary header
+        const fd = new FormData();
+        Object.entries(fields).forEach(([k, v]) => v != null && fd.append(k, String(v)));
+        files.forEach((f) => fd.append('attachments', f));
+        body = fd;
+      } else {
+        headers['Content-Type'] = 'application/json';
+        body = JSON.stringify(fields);
+      }
+      const res = await fetch('/api/contact', { method: 'POST', headers, body });
+      if (!res.ok) throw new Error((await res.text()).trim() || 'Failed');
       const data = await res.json();
-      if (!res.ok || !data.ok) throw new Error(data.error || 'Failed');
+      if (!data.ok) throw new Error(data.error || 'Failed');
       setOk(true);
       setName('');
       setEmail('');
       setSubject('');
       setMessage('');
+      setFiles([]);
     } catch (err: any) {
       setOk(false);
       setError(err?.message || 'Failed to send');
     } finally {
       setSending(false);
+      if (widget) setWidgetReset((n) => n + 1);
     }
   }
 
@@ -74,6 +135,31 @@ export default function ContactPage() {
           />
         </div>
 
+        {assetId && (
+          <div className="flex items-center gap-3">
+            {thumb && <img src={thumb} alt="" className="h-16 w-auto rounded-md object-cover" />}
+            <div className="grow">
+              <label className="block text-sm">About this photo</label>
+              <select
+                value={inquiry}
+                onChange={(e) => setInquiry(e.target.value)}
+                className="mt-1 w-full rounded-md border border-neutral-300 bg-white px-3 py-2 text-sm dark:border-neutral-700 dark:bg-neutral-900"
+              >
+                <option value="print">Print</option>
+                <option value="license">Licensing</option>
+                <option value="other">Other question</option>
+              </select>
+            </div>
+            <button
+              type="button"
+              onClick={() => setAssetId('')}
+              className="text-sm opacity-70 hover:opacity-100"
+            >
+              Remove
+            </button>
+          </div>
+        )}
+
         <div>
           <label className="block text-sm">Name *</label>
           <input
@@ -119,6 +205,30 @@ export default function ContactPage() {
           />
         </div>
 
+        <div>
+          <label className="block text-sm">Attachments</label>
+          <input
+            key={files.length ? 'files' : 'empty'}
+            type="file"
+            multiple
+            accept="image/jpeg,image/png,image/webp,image/gif,application/pdf"
+            onChange={(e) => setFiles(Array.from(e.target.files ?? []))}
+            className="mt-1 block w-full text-sm"
+          />
+          <p className="mt-1 text-xs opacity-70">
+            Optional: up to 5 images or PDFs, 10 MB in total.
+          </p>
+        </div>
+
+        {widget && (
+          <CaptchaWidget
+            provider={widget.provider}
+            siteKey={widget.siteKey}
+            resetKey={widgetReset}
+            onToken={setWidgetToken}
+          />
+        )}
+
         <div className="flex items-center gap-3">
           <button
             type="submit"
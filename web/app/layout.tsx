@@ -2,19 +2,20 @@ import './globals.css';
 
 import Navbar from '@/components/Navbar';
 import SiteFooter from '@/components/SiteFooter';
+import { shareMetadata, siteUrl } from '@/lib/meta';
 
 import Providers from './providers';
 import { Metadata } from 'next';
 
 export const metadata: Metadata = {
-  metadataBase: new URL('https://photo.stephanegelibert.com'),
+  metadataBase: new URL(siteUrl),
   title: {
     default: 'Stéphane Gelibert — Photo Portfolio',
     template: '%s · Stéphane Gelibert',
   },
   description: "Stéphane Gelibert's photo portfolio",
   applicationName: 'Photo Portfolio',
-  authors: [{ name: 'Stéphane Gelibert', url: 'https://photo.stephanegelibert.com' }],
+  authors: [{ name: 'Stéphane Gelibert', url: siteUrl }],
   alternates: { canonical: '/' },
   robots: { index: true, follow: true },
 
@@ -34,21 +35,7 @@ export const metadata: Metadata = {
     { media: '(prefers-color-scheme: dark)', color: '#0b0b0c' },
   ],
 
-  openGraph: {
-    type: 'website',
-    url: '/',
-    siteName: 'Stéphane Gelibert',
-    title: 'Stéphane Gelibert — Photo Portfolio',
-    description: 'Selected work and gear I love.',
-    images: [{ url: '/og.jpg', width: 1200, height: 630, alt: 'Gallery preview' }],
-  },
-
-  twitter: {
-    card: 'summary_large_image',
-    title: 'Stéphane Gelibert — Photo Portfolio',
-    description: 'Selected work, and gear I love.',
-    images: ['/og.jpg'],
-  },
+  ...shareMetadata(),
 
   manifest: '/site.webmanifest',
 };
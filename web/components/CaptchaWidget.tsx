@@ -0,0 +1,92 @@
+'use client';
+
+import { useEffect, useRef } from 'react';
+
+// Renders the Turnstile or hCaptcha widget named by GET /api/captcha and
+// reports its token. Both expose the same explicit-render API.
+type WidgetApi = {
+  render: (el: HTMLElement, opts: Record<string, unknown>) => string;
+  reset: (id?: string) => void;
+  remove?: (id?: string) => void;
+};
+
+const scripts: Record<string, { src: string; global: string }> = {
+  turnstile: {
+    src: 'https://challenges.cloudflare.com/turnstile/v0/api.js?render=explicit',
+    global: 'turnstile',
+  },
+  hcaptcha: { src: 'https://js.hcaptcha.com/1/api.js?render=explicit', global: 'hcaptcha' },
+};
+
+function loadApi(provider: string): Promise<WidgetApi> {
+  const { src, global } = scripts[provider];
+  const ready = () => (window as any)[global] as WidgetApi | undefined;
+  return new Promise((resolve, reject) => {
+    const api = ready();
+    if (api) return resolve(api);
+    let script = document.querySelector<HTMLScriptElement>(`script[src="${src}"]`);
+    if (!script) {
+      script = document.createElement('script');
+      script.src = src;
+      script.async = true;
+      document.head.appendChild(script);
+    }
+    script.addEventListener('load', () => {
+      const api = ready();
+      if (api) resolve(api);
+      else reject(new Error(`${provider} failed to load`));
+    });
+    script.addEventListener('error', () => reject(new Error(`${provider} failed to load`)));
+  });
+}
+
+export function isWidgetProvider(provider?: string): boolean {
+  return !!provider && provider in scripts;
+}
+
+export default function CaptchaWidget({
+  provider,
+  siteKey,
+  resetKey,
+  onToken,
+}: {
+  provider: string;
+  siteKey: string;
+  resetKey: number; // bump after each submission; tokens are single-use
+  onToken: (token: string | undefined) => void;
+}) {
+  const el = useRef<HTMLDivElement>(null);
+  const widget = useRef<{ api: WidgetApi; id: string } | null>(null);
+  const tokenCb = useRef(onToken);
+  tokenCb.current = onToken;
+
+  useEffect(() => {
+    let cancelled = false;
+    loadApi(provider)
+      .then((api) => {
+        if (cancelled || !el.current) return;
+        const id = api.render(el.current, {
+          sitekey: siteKey,
+          callback: (token: string) => tokenCb.current(token),
+          'expired-callback': () => tokenCb.current(undefined),
+          'error-callback': () => tokenCb.current(undefined),
+        });
+        widget.current = { api, id };
+      })
+      .catch(() => tokenCb.current(undefined));
+    return () => {
+      cancelled = true;
+      widget.current?.api.remove?.(widget.current.id);
+      widget.current = null;
+    };
+  }, [provider, siteKey]);
+
+  useEffect(() => {
+    if (resetKey && widget.current) {
+      widget.current.api.reset(widget.current.id);
+      tokenCb.current(undefined);
+    }
+  }, [resetKey]);
+
+  return <div ref={el} />;
+}
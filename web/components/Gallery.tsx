@@ -0,0 +1,236 @@
+'use client';
+import Image from 'next/image';
+import { useEffect, useMemo, useState } from 'react';
+
+import Masonry from '@/components/Masonry';
+import Modal from '@/components/Modal';
+import { TagFilter } from '@/components/TagFilter';
+
+export type Tag = { id: string; name?: string | null; value?: string | null };
+export type Exif = {
+  make?: string;
+  model?: string;
+  lensModel?: string;
+  fNumber?: number;
+  exposureTime?: string | number;
+  iso?: number;
+  focalLength?: number;
+  dateTimeOriginal?: string;
+  timeZone?: string;
+  exifImageWidth?: number;
+  exifImageHeight?: number;
+};
+
+export type ExifDisplay = {
+  camera?: string;
+  lens?: string;
+  exposure?: string;
+  aperture?: string;
+  focalLength?: string;
+  focalLength35?: string;
+  iso?: string;
+};
+
+export type Item = {
+  id: string;
+  originalFileName?: string;
+  exif: Exif;
+  display?: ExifDisplay;
+  tags: Tag[];
+  description?: string;
+  rating?: number;
+  favorite?: boolean;
+  previewPath?: string; // "preview/<id>.jpg"
+  thumbHash?: string;
+};
+
+export type Cache = {
+  album: { id: string; name: string; assetCount: number };
+  items: Item[];
+  featured?: string[]; // favorite IDs, best rated first
+};
+
+export default function Gallery() {
+  const [data, setData] = useState<Cache | null>(null);
+  const [selected, setSelected] = useState<Item | null>(null);
+  const [activeTags, setActiveTags] = useState<string[]>([]);
+
+  useEffect(() => {
+    (async () => {
+      try {
+        const res = await fetch('./photos/cache.json', { cache: 'force-cache' });
+        const json = res.ok
+          ? await res.json()
+          : { album: { id: '', name: 'Unknown', assetCount: 0 }, items: [] };
+        setData(json);
+        // deep links from feeds, sitemaps and embeds: /?photo=<id>
+        const id = new URLSearchParams(window.location.search).get('photo');
+        const hit = id ? json.items?.find((it: Item) => it.id === id) : undefined;
+        if (hit) setSelected(hit);
+      } catch {
+        setData({ album: { id: '', name: 'Unknown', assetCount: 0 }, items: [] });
+      }
+    })();
+  }, []);
+
+  const allTags = useMemo(() => {
+    const byId = new Map<string, { label: string; count: number }>();
+
+    data?.items.forEach((it) => {
+      it.tags?.forEach((t) => {
+        const id = t.id;
+        const label = (t.name || t.value || 'tag').toString();
+        const prev = byId.get(id);
+        if (prev) {
+          prev.count += 1;
+        } else {
+          byId.set(id, { label, count: 1 });
+        }
+      });
+    });
+
+    // sort: most occurrences first, then A→Z by label
+    return Array.from(byId, ([id, v]) => ({ id, label: v.label, count: v.count }))
+      .sort((a, b) => b.count - a.count || a.label.localeCompare(b.label))
+      .map(({ id, label }) => ({ id, label }));
+  }, [data]);
+
+  const filtered = useMemo(() => {
+    if (!data) return [];
+    if (!activeTags.length) {
+      // featured favorites lead the unfiltered home view
+      const rank = new Map((data.featured ?? []).map((id, i) => [id, i]));
+      const featured = data.items
+        .filter((it) => rank.has(it.id))
+        .sort((a, b) => rank.get(a.id)! - rank.get(b.id)!);
+      const rest = data.items.filter((it) => !rank.has(it.id));
+      return [...featured, ...rest].filter((it) => !!it.previewPath);
+    }
+    const base = data.items.filter((it) => it.tags?.some((t) => activeTags.includes(t.id)));
+    return base.filter((it) => !!it.previewPath);
+  }, [data, activeTags]);
+
+  return (
+    <div className="space-y-6">
+      <div className="flex flex-col md:flex-row md:items-end gap-4">
+        <div>
+          <h1 className="text-2xl font-bold">{data?.album?.name || 'Album'}</h1>
+          <p className="text-sm opacity-80">
+            {filtered.length} photo{filtered.length === 1 ? '' : 's'}
+          </p>
+        </div>
+        <div className="flex-1" />
+        <TagFilter tags={allTags} active={activeTags} onChange={setActiveTags} />
+      </div>
+
+      <Masonry>
+        {filtered.map((it) => (
+          <button
+            key={it.id}
+            onClick={() => setSelected(it)}
+            className="block w-full focus:outline-none focus-visible:ring-2 focus-visible:ring-accent rounded-lg"
+          >
+            {it.thumbHash && (
+              <img
+                src={(() => {
+                  const { thumbhashToDataURL } = require('@/lib/thumbhash');
+                  return thumbhashToDataURL(it.thumbHash);
+                })()}
+                alt=""
+                aria-hidden
+                className="w-full h-auto rounded-lg object-cover"
+              />
+            )}
+            <img
+              src={`/photos/${it.previewPath}`}
+              alt={it.originalFileName || ''}
+              loading="lazy"
+              onLoad={(e) => {
+                const el = e.currentTarget;
+                el.previousElementSibling?.remove();
+              }}
+              className="w-full h-auto rounded-lg object-cover transition-transform duration-300 hover:scale-[1.02]"
+            />
+          </button>
+        ))}
+      </Masonry>
+
+      <Modal open={!!selected} onClose={() => setSelected(null)}>
+        {selected && (
+          <div className="flex flex-col lg:flex-row lg:items-start gap-y-6 lg:gap-x-4">
+            <div className="shrink-0">
+              <img
+                src={`/photos/${selected.previewPath}`}
+                alt={selected.originalFileName || ''}
+                className="max-h-[85vh] max-w-[85vw] h-auto w-auto object-contain rounded-xl shadow"
+              />
+            </div>
+            <div className="min-w-[260px] lg:max-w-[40vw] grow overflow-auto space-y-2 text-sm">
+              {selected.description && (
+                <p className="pb-2 whitespace-pre-line">{selected.description}</p>
+              )}
+              <h3 className="text-lg font-semibold">EXIF</h3>
+              <dl className="grid grid-cols-2 gap-y-2">
+                <dt className="opacity-70">Camera</dt>
+                <dd>{selected.display?.camera || selected.exif.model || '—'}</dd>
+                <dt className="opacity-70">Lens</dt>
+                <dd>{selected.display?.lens || selected.exif.lensModel || '—'}</dd>
+                <dt className="opacity-70">Focal</dt>
+                <dd>
+                  {selected.display?.focalLength || '—'}
+                  {selected.display?.focalLength35 &&
+                    selected.display.focalLength35 !== selected.display.focalLength && (
+                      <span className="opacity-70"> ({selected.display.focalLength35} eq.)</span>
+                    )}
+                </dd>
+                <dt className="opacity-70">Aperture</dt>
+                <dd>{selected.display?.aperture || '—'}</dd>
+                <dt className="opacity-70">Shutter speed</dt>
+                <dd>{selected.display?.exposure || '—'}</dd>
+                <dt className="opacity-70">ISO</dt>
+                <dd>{selected.exif.iso ?? '—'}</dd>
+                <dt className="opacity-70">Taken</dt>
+                <dd>
+                  {selected.exif.dateTimeOriginal
+                    ? new Date(selected.exif.dateTimeOriginal).toLocaleString('en-US', {
+                        year: 'numeric',
+                        month: 'long',
+                        day: 'numeric',
+                      })
+                    : '—'}
+                </dd>
+              </dl>
+              <div className="pt-4">
+                <h4 className="font-semibold mb-2">Tags</h4>
+                <div className="flex flex-wrap gap-2">
+                  {(selected.tags || []).map((t) => (
+                    <span
+                      key={t.id}
+                      className="px-2 py-1 rounded-full text-xs bg-neutral-200 dark:bg-neutral-800"
+                    >
+                      {t.name || t.value || 'tag'}
+                    </span>
+                  ))}
+                </div>
+              </div>
+              <div className="pt-4 flex gap-3">
+                <a
+                  href={`/contact?photo=${encodeURIComponent(selected.id)}&inquiry=print`}
+                  className="text-sm underline underline-offset-4 decoration-accent/60 hover:decoration-accent"
+                >
+                  Order a print
+                </a>
+                <a
+                  href={`/contact?photo=${encodeURIComponent(selected.id)}&inquiry=license`}
+                  className="text-sm underline underline-offset-4 decoration-accent/60 hover:decoration-accent"
+                >
+                  License this photo
+                </a>
+              </div>
+            </div>
+          </div>
+        )}
+      </Modal>
+    </div>
+  );
+}
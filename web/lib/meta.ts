@@ -0,0 +1,36 @@
+import type { Metadata } from 'next';
+
+// Public origin of the site; the API's PUBLIC_URL must match it for oEmbed.
+export const siteUrl = 'https://photo.stephanegelibert.com';
+
+// Share cards are rendered by the API (GET /api/og/album, /api/og/photo/{id}).
+// Next replaces openGraph and twitter as a whole per page, so pages build on this.
+export function shareMetadata(photo?: string): Pick<Metadata, 'openGraph' | 'twitter'> {
+  const card = photo ? `/api/og/photo/${encodeURIComponent(photo)}` : '/api/og/album';
+  return {
+    openGraph: {
+      type: 'website',
+      url: photo ? `/?photo=${encodeURIComponent(photo)}` : '/',
+      siteName: 'Stéphane Gelibert',
+      title: 'Stéphane Gelibert — Photo Portfolio',
+      description: 'Selected work and gear I love.',
+      images: [{ url: card, width: 1200, height: 630, alt: photo ? 'Photo preview' : 'Gallery preview' }],
+    },
+    twitter: {
+      card: 'summary_large_image',
+      title: 'Stéphane Gelibert — Photo Portfolio',
+      description: 'Selected work, and gear I love.',
+      images: [card],
+    },
+  };
+}
+
+// oEmbed discovery for a photo deep link, so blogs and Notion find /api/oembed.
+// Next replaces alternates as a whole, so the layout's canonical is repeated.
+export function photoAlternates(photo: string): Metadata['alternates'] {
+  const page = `${siteUrl}/?photo=${encodeURIComponent(photo)}`;
+  return {
+    canonical: '/',
+    types: { 'application/json+oembed': `/api/oembed?url=${encodeURIComponent(page)}` },
+  };
+}
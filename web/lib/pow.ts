@@ -0,0 +1,36 @@
+// Solves the server's proof-of-work challenge (GET /api/captcha, provider "pow"):
+// find a nonce so that sha256(`${challenge}:${nonce}`) starts with `difficulty` zero bits.
+
+function leadingZeroBits(bytes: Uint8Array): number {
+  let n = 0;
+  for (const b of bytes) {
+    if (b === 0) {
+      n += 8;
+      continue;
+    }
+    return n + Math.clz32(b) - 24;
+  }
+  return n;
+}
+
+export async function solvePow(challenge: string, difficulty: number): Promise<string> {
+  const enc = new TextEncoder();
+  for (let nonce = 0; ; nonce++) {
+    const digest = await crypto.subtle.digest('SHA-256', enc.encode(`${challenge}:${nonce}`));
+    if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+      return `${challenge}:${nonce}`;
+    }
+  }
+}
+
+// captchaToken returns the proof-of-work token for POST /api/contact, or undefined
+// when no puzzle is required. Turnstile and hCaptcha tokens come from components/CaptchaWidget.
+export async function captchaToken(): Promise<string | undefined> {
+  const res = await fetch('/api/captcha', { cache: 'no-store' });
+  if (!res.ok) return undefined;
+  const cfg = await res.json();
+  if (cfg.provider === 'pow' && cfg.pow) {
+    return solvePow(cfg.pow.challenge, cfg.pow.difficulty);
+  }
+  return undefined;
+}
@@ -5,6 +5,7 @@ const nextConfig = {
   async rewrites() {
     return [
       { source: '/api/:path*', destination: `${process.env.NEXT_PUBLIC_API_BASE}/api/:path*` },
+      { source: '/sitemap.xml', destination: `${process.env.NEXT_PUBLIC_API_BASE}/api/sitemap.xml` },
     ];
   },
 };